@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/database"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/shopspring/decimal"
+)
+
+// 空投快照工具
+//
+// 生成快照：
+//
+//	go run ./cmd/airdrop -name season1 -points 1 -tips 2 -checkins 10 -total 1000000 -out season1.json
+//
+// 导出已有快照（merkle-distributor 格式）：
+//
+//	go run ./cmd/airdrop -export season1 -out season1.json
+func main() {
+	name := flag.String("name", "", "快照名称")
+	export := flag.String("export", "", "导出已有快照（不重新计算）")
+	out := flag.String("out", "", "输出 merkle-distributor JSON 文件路径")
+	decimals := flag.Int("decimals", 18, "代币精度")
+	base := flag.String("base", "0", "基础分（每个有记录的钱包）")
+	points := flag.String("points", "1", "当前积分权重")
+	tips := flag.String("tips", "0", "累计打赏积分权重")
+	checkIns := flag.String("checkins", "0", "签到次数权重")
+	streak := flag.String("streak", "0", "最高连签天数权重")
+	total := flag.String("total", "0", "空投总量（0=得分即数量）")
+	minAmount := flag.String("min", "0", "最低分配量")
+	maxAmount := flag.String("max", "0", "单钱包上限（0=不限）")
+	flag.Parse()
+
+	if *name == "" && *export == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	db := database.Connect(cfg)
+	airdropService := services.NewAirdropService(db, cfg)
+
+	snapshotName := *export
+	if snapshotName == "" {
+		formula := services.AirdropFormula{
+			BaseAmount:    mustDecimal("base", *base),
+			PointsWeight:  mustDecimal("points", *points),
+			TipsWeight:    mustDecimal("tips", *tips),
+			CheckInWeight: mustDecimal("checkins", *checkIns),
+			StreakWeight:  mustDecimal("streak", *streak),
+			TotalSupply:   mustDecimal("total", *total),
+			MinAmount:     mustDecimal("min", *minAmount),
+			MaxAmount:     mustDecimal("max", *maxAmount),
+		}
+
+		snapshot, err := airdropService.CreateSnapshot(*name, formula, *decimals)
+		if err != nil {
+			log.Fatalf("Failed to create snapshot: %v", err)
+		}
+		snapshotName = snapshot.Name
+
+		fmt.Println("=== 空投快照已生成 ===")
+		fmt.Println("名称:", snapshot.Name)
+		fmt.Println("Merkle Root:", snapshot.MerkleRoot)
+		fmt.Println("钱包数:", snapshot.WalletCount)
+		fmt.Println("总量:", snapshot.TokenTotal.String())
+	}
+
+	if *out != "" {
+		info, err := airdropService.ExportDistributorInfo(snapshotName)
+		if err != nil {
+			log.Fatalf("Failed to export snapshot: %v", err)
+		}
+		data, _ := json.MarshalIndent(info, "", "  ")
+		if err := os.WriteFile(*out, data, 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v", *out, err)
+		}
+		fmt.Println("已导出:", *out)
+	}
+}
+
+func mustDecimal(name string, value string) decimal.Decimal {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		log.Fatalf("Invalid -%s: %v", name, err)
+	}
+	return d
+}
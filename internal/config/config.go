@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 type Config struct {
@@ -28,15 +29,20 @@ type Config struct {
 	DepositConfirms    int     // 充值确认区块数
 	
 	// 费率配置
-	TipFeeRate         float64 // 打赏平台抽成比例（如0.05表示5%）
-	WithdrawFeeRate    float64 // 提现手续费比例
+	TipFeeRate         float64 // 打赏平台抽成比例（如0.05表示5%），未配置分档规则时的默认费率
+	WithdrawFeeRate    float64 // 提现手续费比例，未配置分档规则时的默认费率
+	FeeVolumeDays      int     // 手续费分档的交易量统计窗口（天）
 	MinWithdrawAmount  float64 // 最低提现金额（代币数量）
 	MinDepositAmount   float64 // 最低充值金额（代币数量）
+	AgentWalletCooldownHours int // Agent更换提现钱包后的提现冷却（小时）
 	
 	// 激励池税费分配比例
 	TaxToRewardPool    float64 // 税费进激励池比例（如0.5表示50%）
 	TaxToBuyback       float64 // 税费用于回购销毁比例
 	TaxToOperation     float64 // 税费用于运营比例
+
+	// 奖励解锁
+	RewardVestingEnabled bool  // 奖励是否按 RewardConfig.VestingDays 线性解锁
 	
 	// IP限制
 	EnableGeoBlock     bool     // 是否启用地区限制
@@ -44,6 +50,62 @@ type Config struct {
 	
 	// 加密密钥
 	EncryptionKey      string  // AES加密密钥（用于加密私钥等敏感信息）
+
+	// 管理员
+	AdminWallets       []string // 管理员钱包地址（登录后可访问管理接口）
+
+	// 反女巫风控
+	RiskEnabled        bool    // 是否对用户奖励启用风控评分
+	RiskReduceScore    int     // 评分低于此值时奖励打折
+	RiskDeferScore     int     // 评分低于此值时奖励延迟发放（人工审核）
+	RiskReduceRate     float64 // 打折后发放比例（如0.5表示发一半）
+
+	// 储备金证明
+	ReserveProofHours  int     // 生成储备金证明的间隔（小时，0=不生成）
+
+	// Agent 质押
+	StakeEpochHours    int     // 分红周期（小时）
+	StakeFeeShare      float64 // 每个周期分给质押者的打赏手续费比例（如0.5表示50%）
+	StakeUnbondHours   int     // 解除质押的冷却期（小时）
+	MinStakeAmount     float64 // 单笔最低质押数量
+
+	// 悬赏
+	MinBountyAmount    float64 // 最低悬赏金额
+	MaxBountyDays      int     // 回答期最长天数
+	BountyJudgeHours   int     // 回答截止后发布者评选的时间（小时）
+
+	// Agent 互相打赏
+	AgentTipWindowHours int    // 循环打赏检测的时间窗口（小时）
+	AgentTipCycleDepth  int    // 循环打赏检测的最大链路长度（A→B→…→A）
+
+	// 搜索
+	SearchHotnessWeight float64 // 搜索排序中热度的权重（相关度 + 权重 * ln(1+热度)）
+
+	// 为你推荐
+	FeedScorer          string  // 推荐打分器名称（默认 affinity）
+	FeedHistoryDays     int     // 兴趣画像使用的互动历史（天）
+	FeedSeenHours       int     // 已推荐帖子在多少小时内不再推荐
+	FeedExploreRate     float64 // 每页用于探索的比例
+
+	// 热度
+	HotnessGravity         float64 // 时间衰减系数（越大衰减越快）
+	HotnessLikeWeight      float64 // 点赞权重
+	HotnessCommentWeight   float64 // 评论权重
+	HotnessTipWeight       float64 // 打赏次数权重
+	HotnessTipAmountWeight float64 // 代币打赏总额权重（按 ln(1+总额)）
+	HotnessWindowDays      int     // 后台重算的帖子发布窗口（天）
+	HotnessIntervalMinutes int     // 后台重算间隔（分钟）
+	RisingHours            int     // rising 统计最近多少小时的互动
+	RisingMaxAgeHours      int     // 只有发布不超过此时长的帖子参与 rising
+
+	// 评论
+	CommentMaxDepth    int // 楼中楼最大层级（顶层评论为 0）
+	CommentThreadLimit int // thread 接口最多返回的评论数
+	AgentCommentLimit  int // Agent 每小时最多评论数
+	CommentMaxImages   int // 每条评论最多图片数
+
+	// 帖子编辑
+	PostEditWindowMinutes int // 发布后多少分钟内允许 Agent 编辑
 }
 
 func Load() *Config {
@@ -81,13 +143,18 @@ func Load() *Config {
 		// 费率配置
 		TipFeeRate:        getEnvFloat("TIP_FEE_RATE", 0.05),        // 5%
 		WithdrawFeeRate:   getEnvFloat("WITHDRAW_FEE_RATE", 0.02),   // 2%
+		FeeVolumeDays:     getEnvInt("FEE_VOLUME_DAYS", 30),         // 按近30天交易量分档
 		MinWithdrawAmount: getEnvFloat("MIN_WITHDRAW", 100000),      // 10万代币
 		MinDepositAmount:  getEnvFloat("MIN_DEPOSIT", 0),       // 10万代币（约$0.6）
+		AgentWalletCooldownHours: getEnvInt("AGENT_WALLET_COOLDOWN_HOURS", 72), // 更换钱包后72小时内不可提现
 		
 		// 激励池税费分配
 		TaxToRewardPool:   getEnvFloat("TAX_TO_REWARD", 0.5),        // 50%
 		TaxToBuyback:      getEnvFloat("TAX_TO_BUYBACK", 0.2),       // 20%
 		TaxToOperation:    getEnvFloat("TAX_TO_OPERATION", 0.3),     // 30%
+
+		// 奖励解锁
+		RewardVestingEnabled: getEnvBool("REWARD_VESTING_ENABLED", false),
 		
 		// IP限制
 		EnableGeoBlock:    getEnvBool("ENABLE_GEO_BLOCK", true),
@@ -95,6 +162,62 @@ func Load() *Config {
 		
 		// 加密密钥
 		EncryptionKey:     encryptionKey,
+
+		// 管理员
+		AdminWallets:      getEnvList("ADMIN_WALLETS"),
+
+		// 反女巫风控
+		RiskEnabled:       getEnvBool("RISK_ENABLED", true),
+		RiskReduceScore:   getEnvInt("RISK_REDUCE_SCORE", 60),
+		RiskDeferScore:    getEnvInt("RISK_DEFER_SCORE", 30),
+		RiskReduceRate:    getEnvFloat("RISK_REDUCE_RATE", 0.5),
+
+		// 储备金证明
+		ReserveProofHours: getEnvInt("RESERVE_PROOF_HOURS", 6),
+
+		// Agent 质押
+		StakeEpochHours:   getEnvInt("STAKE_EPOCH_HOURS", 24),
+		StakeFeeShare:     getEnvFloat("STAKE_FEE_SHARE", 0.5),
+		StakeUnbondHours:  getEnvInt("STAKE_UNBOND_HOURS", 72),
+		MinStakeAmount:    getEnvFloat("MIN_STAKE", 10000),
+
+		// 悬赏
+		MinBountyAmount:   getEnvFloat("MIN_BOUNTY", 10000),
+		MaxBountyDays:     getEnvInt("MAX_BOUNTY_DAYS", 30),
+		BountyJudgeHours:  getEnvInt("BOUNTY_JUDGE_HOURS", 72),
+
+		// Agent 互相打赏
+		AgentTipWindowHours: getEnvInt("AGENT_TIP_WINDOW_HOURS", 72),
+		AgentTipCycleDepth:  getEnvInt("AGENT_TIP_CYCLE_DEPTH", 3),
+
+		// 搜索
+		SearchHotnessWeight: getEnvFloat("SEARCH_HOTNESS_WEIGHT", 0.1),
+
+		// 为你推荐
+		FeedScorer:          getEnv("FEED_SCORER", "affinity"),
+		FeedHistoryDays:     getEnvInt("FEED_HISTORY_DAYS", 90),
+		FeedSeenHours:       getEnvInt("FEED_SEEN_HOURS", 72),
+		FeedExploreRate:     getEnvFloat("FEED_EXPLORE_RATE", 0.2),
+
+		// 热度
+		HotnessGravity:         getEnvFloat("HOTNESS_GRAVITY", 1.5),
+		HotnessLikeWeight:      getEnvFloat("HOTNESS_LIKE_WEIGHT", 1),
+		HotnessCommentWeight:   getEnvFloat("HOTNESS_COMMENT_WEIGHT", 3),
+		HotnessTipWeight:       getEnvFloat("HOTNESS_TIP_WEIGHT", 10),
+		HotnessTipAmountWeight: getEnvFloat("HOTNESS_TIP_AMOUNT_WEIGHT", 2),
+		HotnessWindowDays:      getEnvInt("HOTNESS_WINDOW_DAYS", 7),
+		HotnessIntervalMinutes: getEnvInt("HOTNESS_INTERVAL_MINUTES", 10),
+		RisingHours:            getEnvInt("RISING_HOURS", 6),
+		RisingMaxAgeHours:      getEnvInt("RISING_MAX_AGE_HOURS", 48),
+
+		// 评论
+		CommentMaxDepth:    getEnvInt("COMMENT_MAX_DEPTH", 5),
+		CommentThreadLimit: getEnvInt("COMMENT_THREAD_LIMIT", 200),
+		AgentCommentLimit:  getEnvInt("AGENT_COMMENT_LIMIT", 30),
+		CommentMaxImages:   getEnvInt("COMMENT_MAX_IMAGES", 4),
+
+		// 帖子编辑
+		PostEditWindowMinutes: getEnvInt("POST_EDIT_WINDOW_MINUTES", 60),
 	}
 }
 
@@ -132,3 +255,14 @@ func getEnvFloat(key string, defaultVal float64) float64 {
 	}
 	return defaultVal
 }
+
+// getEnvList 读取逗号分隔的列表（统一转小写）
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, strings.ToLower(item))
+		}
+	}
+	return list
+}
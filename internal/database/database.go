@@ -23,6 +23,10 @@ func Connect(cfg *config.Config) *gorm.DB {
 		&models.PostImage{},
 		&models.PostVideo{},
 		&models.Comment{},
+		&models.CommentLike{},
+		&models.CommentImage{},
+		&models.CommentVideo{},
+		&models.Upload{},
 		&models.Like{},
 		&models.AgentApplication{},
 		&models.Topic{},
@@ -30,24 +34,101 @@ func Connect(cfg *config.Config) *gorm.DB {
 		&models.AgentRateLimit{},
 		&models.TipRecord{},
 		&models.CheckInRecord{},
+		&models.AgentOwnerLog{},
+		&models.Follow{},
+		&models.FeedImpression{},
+		&models.PostActivity{},
+		&models.PostRevision{},
 	)
 
 	// Auto migrate - 代币系统模型
 	db.AutoMigrate(
 		&models.TokenBalance{},
 		&models.AgentTokenBalance{},
+		&models.AgentWalletBinding{},
 		&models.DepositAddress{},
 		&models.Deposit{},
 		&models.Withdrawal{},
 		&models.TokenTip{},
+		&models.PostUnlock{},
 		&models.RewardPool{},
 		&models.RewardPoolDeposit{},
 		&models.Reward{},
 		&models.RewardConfig{},
 		&models.UserDailyReward{},
+		&models.VestingGrant{},
+		&models.RewardCampaign{},
 		&models.PlatformIncome{},
+		&models.FeeRule{},
+		&models.AgentStake{},
+		&models.StakeEpoch{},
+		&models.StakeReward{},
+		&models.AnalyticsDaily{},
 		&models.SystemConfig{},
+		&models.AdminAuditLog{},
 	)
 
+	// Auto migrate - 风控模型
+	db.AutoMigrate(
+		&models.WalletFingerprint{},
+		&models.WalletRiskProfile{},
+	)
+
+	// Auto migrate - 空投模型
+	db.AutoMigrate(
+		&models.AirdropSnapshot{},
+		&models.AirdropAllocation{},
+	)
+
+	// Auto migrate - 储备金证明模型
+	db.AutoMigrate(
+		&models.ReserveSnapshot{},
+		&models.ReserveLeaf{},
+	)
+
+	// Auto migrate - 悬赏模型
+	db.AutoMigrate(
+		&models.Bounty{},
+		&models.BountyAnswer{},
+	)
+
+	setupSearchIndexes(db)
+	setupFeedIndexes(db)
+
 	return db
 }
+
+// setupFeedIndexes 信息流排序索引
+func setupFeedIndexes(db *gorm.DB) {
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_posts_hot ON posts (hotness_score DESC, posted_at DESC, id DESC) WHERE deleted_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_posts_new ON posts (posted_at DESC, id DESC) WHERE deleted_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_posts_top ON posts (engagement_score DESC, posted_at DESC, id DESC) WHERE deleted_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_posts_rising ON posts (rising_score DESC, posted_at DESC, id DESC) WHERE deleted_at IS NULL AND rising_score > 0",
+		"CREATE INDEX IF NOT EXISTS idx_posts_controversial ON posts (controversy_score DESC, posted_at DESC, id DESC) WHERE deleted_at IS NULL AND controversy_score > 0",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Printf("Warning: Failed to create feed index: %v", err)
+		}
+	}
+}
+
+// setupSearchIndexes 全文搜索索引（tsvector GIN + pg_trgm）
+func setupSearchIndexes(db *gorm.DB) {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Printf("Warning: Failed to enable pg_trgm, search will not work: %v", err)
+		return
+	}
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_posts_search_text_trgm ON posts USING GIN (search_text gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_agents_search_vector ON agents USING GIN (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_agents_username_trgm ON agents USING GIN (username gin_trgm_ops)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Printf("Warning: Failed to create search index: %v", err)
+		}
+	}
+}
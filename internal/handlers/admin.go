@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -91,6 +92,15 @@ func (h *Handler) AdminCreatePost(c *gin.Context) {
 	
 	// 更新 Agent 的帖子数
 	h.DB.Model(&agent).UpdateColumn("posts_count", agent.PostsCount+1)
+	services.NewSearchService(h.DB, h.Cfg).IndexPost(&post)
 	
 	c.JSON(http.StatusCreated, gin.H{"post": post})
 }
+
+// adminActor 当前管理员（用于审计记录）
+func adminActor(c *gin.Context) services.AdminActor {
+	return services.AdminActor{
+		Wallet: c.GetString("admin_wallet"),
+		IP:     c.ClientIP(),
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// ==================== 奖励配置管理（管理员）====================
+
+// AdminGetRewardConfigs 获取奖励配置
+func (h *Handler) AdminGetRewardConfigs(c *gin.Context) {
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	configs, err := rewardService.ListRewardConfigs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取奖励配置失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"configs": configs})
+}
+
+// AdminUpdateRewardConfig 修改奖励配置
+func (h *Handler) AdminUpdateRewardConfig(c *gin.Context) {
+	rewardType := c.Param("type")
+
+	var req struct {
+		Amount      *string `json:"amount"` // 字符串避免精度丢失
+		DailyLimit  *int    `json:"dailyLimit"`
+		TotalLimit  *int    `json:"totalLimit"`
+		IsActive    *bool   `json:"isActive"`
+		VestingDays *int    `json:"vestingDays"`
+		PoolName    *string `json:"poolName"`
+		Description *string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	update := services.RewardConfigUpdate{
+		DailyLimit:  req.DailyLimit,
+		TotalLimit:  req.TotalLimit,
+		IsActive:    req.IsActive,
+		VestingDays: req.VestingDays,
+		PoolName:    req.PoolName,
+		Description: req.Description,
+	}
+	if req.Amount != nil {
+		amount, err := decimal.NewFromString(*req.Amount)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "奖励金额无效"})
+			return
+		}
+		update.Amount = &amount
+	}
+
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	cfg, err := rewardService.UpdateRewardConfig(rewardType, update, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "config": cfg})
+}
+
+// ==================== 激励池管理（管理员）====================
+
+// AdminGetRewardPools 获取所有激励池
+func (h *Handler) AdminGetRewardPools(c *gin.Context) {
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	pools, err := rewardService.ListRewardPools()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取激励池失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pools": pools})
+}
+
+// AdminCreateRewardPool 创建激励池
+func (h *Handler) AdminCreateRewardPool(c *gin.Context) {
+	var req struct {
+		Name           string `json:"name" binding:"required,min=2,max=50"`
+		InitialBalance string `json:"initialBalance"`
+		Note           string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	initialBalance := decimal.Zero
+	if req.InitialBalance != "" {
+		amount, err := decimal.NewFromString(req.InitialBalance)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "初始余额无效"})
+			return
+		}
+		initialBalance = amount
+	}
+
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	pool, err := rewardService.CreateRewardPool(req.Name, initialBalance, req.Note, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "pool": pool})
+}
+
+// AdminUpdateRewardPool 启用/停用激励池
+func (h *Handler) AdminUpdateRewardPool(c *gin.Context) {
+	var req struct {
+		IsActive *bool `json:"isActive" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	pool, err := rewardService.SetRewardPoolActive(c.Param("name"), *req.IsActive, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "pool": pool})
+}
+
+// AdminDepositToPool 向激励池注入资金
+func (h *Handler) AdminDepositToPool(c *gin.Context) {
+	var req struct {
+		Amount string `json:"amount" binding:"required"`
+		Source string `json:"source" binding:"omitempty,oneof=tax manual other"`
+		TxHash string `json:"txHash"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "注入金额无效"})
+		return
+	}
+
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	deposit, err := rewardService.AdminDepositToPool(c.Param("name"), amount, req.Source, req.TxHash, req.Note, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "deposit": deposit})
+}
+
+// AdminGetPoolDeposits 获取激励池注入记录
+func (h *Handler) AdminGetPoolDeposits(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	deposits, total, err := rewardService.GetPoolDeposits(c.Param("name"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deposits": deposits,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// ==================== 审计记录（管理员）====================
+
+// AdminGetAuditLogs 获取管理员操作审计记录
+func (h *Handler) AdminGetAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	logs, total, err := services.GetAuditLogs(h.DB, c.Query("targetType"), c.Query("targetId"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审计记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":  logs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== Agent 评论 ====================
+
+// AgentCreateComment Agent 评论帖子或回复评论（带 parentId 时为回复，可附图片或视频）
+func (h *Handler) AgentCreateComment(c *gin.Context) {
+	agent := c.MustGet("agent").(models.Agent)
+	postID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var req struct {
+		Content  string   `json:"content" binding:"required,max=500"`
+		ParentID *uint    `json:"parentId"`
+		Images   []string `json:"images"`   // 先通过 /upload 上传
+		VideoURL string   `json:"videoUrl"` // 与 images 二选一
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.takeCommentQuota(agent.ID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Rate limit exceeded",
+			"message": "Maximum " + strconv.Itoa(h.Cfg.AgentCommentLimit) + " comments per hour",
+		})
+		return
+	}
+
+	comment, err := services.NewCommentService(h.DB, h.Cfg).Create(services.CommentInput{
+		PostID:   uint(postID),
+		ParentID: req.ParentID,
+		AgentID:  &agent.ID,
+		Content:  req.Content,
+		Images:   req.Images,
+		VideoURL: req.VideoURL,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	go h.recordActivity(uint(postID), services.ActivityDelta{Comments: 1})
+
+	h.DB.Scopes(services.PreloadComment).First(comment, comment.ID)
+	c.JSON(http.StatusCreated, gin.H{"comment": comment})
+}
+
+// AgentGetCommentInbox Agent 收到的新评论：自己帖子下的评论及对自己评论的回复（按时间正序，游标之后的新增）
+// 客户端保存返回的 nextCursor，下次带上即可只拿到新评论
+func (h *Handler) AgentGetCommentInbox(c *gin.Context) {
+	agent := c.MustGet("agent").(models.Agent)
+	limit := followListLimit(c)
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	var comments []models.Comment
+	h.DB.Scopes(services.PreloadComment).
+		Where("comments.post_id IN (?) OR comments.parent_id IN (?)",
+			h.DB.Model(&models.Post{}).Select("id").Where("agent_id = ?", agent.ID),
+			h.DB.Model(&models.Comment{}).Select("id").Where("agent_id = ?", agent.ID)).
+		Where("comments.agent_id IS NULL OR comments.agent_id <> ?", agent.ID).
+		Scopes(services.KeysetSinceTime("comments", "created_at", cursor)).
+		Limit(limit + 1).
+		Find(&comments)
+
+	hasMore := len(comments) > limit
+	if hasMore {
+		comments = comments[:limit]
+	}
+
+	// 没有新评论时原样返回游标，方便轮询
+	nextCursor := c.Query("cursor")
+	if len(comments) > 0 {
+		last := comments[len(comments)-1]
+		nextCursor = services.TimeCursor(last.CreatedAt, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments":   comments,
+		"hasMore":    hasMore,
+		"nextCursor": nextCursor,
+	})
+}
+
+// takeCommentQuota 占用一次评论额度（窗口过期则重置），超限返回 false
+func (h *Handler) takeCommentQuota(agentID uint) bool {
+	now := time.Now()
+	h.DB.Where(models.AgentRateLimit{AgentID: agentID}).
+		Attrs(models.AgentRateLimit{WindowStart: now}).
+		FirstOrCreate(&models.AgentRateLimit{})
+
+	// 条件更新保证并发请求不会超出额度
+	windowStart := now.Add(-RateLimitWindow)
+	result := h.DB.Model(&models.AgentRateLimit{}).
+		Where("agent_id = ? AND (comment_window_start IS NULL OR comment_window_start < ? OR comment_count < ?)",
+			agentID, windowStart, h.Cfg.AgentCommentLimit).
+		UpdateColumns(map[string]interface{}{
+			"comment_count":        gorm.Expr("CASE WHEN comment_window_start IS NULL OR comment_window_start < ? THEN 1 ELSE comment_count + 1 END", windowStart),
+			"comment_window_start": gorm.Expr("CASE WHEN comment_window_start IS NULL OR comment_window_start < ? THEN CAST(? AS timestamptz) ELSE comment_window_start END", windowStart, now),
+		})
+	return result.Error == nil && result.RowsAffected == 1
+}
@@ -9,6 +9,7 @@ import (
 	
 
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -107,6 +108,9 @@ func (h *Handler) AgentStatus(c *gin.Context) {
 	if agent.IsApproved {
 		status = "claimed"
 	}
+	if agent.IsPaused {
+		status = "paused"
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":       status,
@@ -117,9 +121,10 @@ func (h *Handler) AgentStatus(c *gin.Context) {
 	})
 }
 
-// ClaimAgent - 人类验证 Agent（验证推文）
+// ClaimAgent - 人类验证 Agent（验证推文，需要钱包登录，认领人成为主人）
 func (h *Handler) ClaimAgent(c *gin.Context) {
 	claimCode := c.Param("code")
+	user := c.MustGet("user").(*models.User)
 	
 	var req struct {
 		TweetURL      string `json:"tweetUrl" binding:"required"`
@@ -132,27 +137,16 @@ func (h *Handler) ClaimAgent(c *gin.Context) {
 	}
 
 	// 查找 Agent
-	var agent models.Agent
-	if err := h.DB.Where("claim_code = ?", claimCode).First(&agent).Error; err != nil {
+	var existing models.Agent
+	if err := h.DB.Where("claim_code = ?", claimCode).First(&existing).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid claim code"})
 		return
 	}
 
-	if agent.IsApproved {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Agent already claimed"})
-		return
-	}
-
-	// TODO: 验证推文内容包含验证码
-	// 简化版：直接标记为已验证
-	
-	agent.IsApproved = true
-	agent.Verified = true
-	agent.TwitterHandle = req.TwitterHandle
-	agent.TweetURL = req.TweetURL
-	
-	if err := h.DB.Save(&agent).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update agent"})
+	ownerService := services.NewAgentOwnerService(h.DB, h.Cfg)
+	agent, err := ownerService.ClaimAgent(claimCode, user.ID, req.TwitterHandle, req.TweetURL, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -160,8 +154,10 @@ func (h *Handler) ClaimAgent(c *gin.Context) {
 		"success": true,
 		"message": "Agent claimed successfully! Your AI can now post.",
 		"agent": gin.H{
+			"id":       agent.ID,
 			"name":     agent.Username,
 			"verified": true,
+			"owner":    user.WalletAddress,
 		},
 	})
 }
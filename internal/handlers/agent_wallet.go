@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Agent提现钱包绑定 ====================
+
+// GetAgentPayoutWallet 获取当前绑定的提现钱包
+func (h *Handler) GetAgentPayoutWallet(c *gin.Context) {
+	agent := c.MustGet("agent").(models.Agent)
+
+	walletService := services.NewAgentWalletService(h.DB, h.Cfg)
+	bindings, err := walletService.GetBindingHistory(agent.ID, 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取绑定记录失败"})
+		return
+	}
+
+	balance, err := walletService.GetPayoutWallet(agent.ID)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"walletAddress": "", "walletVerified": false, "bindings": bindings})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"walletAddress":  balance.WalletAddress,
+		"walletVerified": balance.WalletVerified,
+		"walletBoundAt":  balance.WalletBoundAt,
+		"withdrawableAt": balance.WithdrawableAt,
+		"bindings":       bindings,
+	})
+}
+
+// GetMyAgentPayoutWalletMessage 主人获取需要钱包签名的绑定消息
+func (h *Handler) GetMyAgentPayoutWalletMessage(c *gin.Context) {
+	agent, ok := h.ownedAgent(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		WalletAddress string `json:"walletAddress" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if !common.IsHexAddress(req.WalletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的钱包地址"})
+		return
+	}
+
+	// 更换已验证的钱包时，这条消息还需当前钱包签名
+	currentWallet := ""
+	walletService := services.NewAgentWalletService(h.DB, h.Cfg)
+	if current, err := walletService.GetPayoutWallet(agent.ID); err == nil && current.WalletVerified &&
+		!strings.EqualFold(current.WalletAddress, req.WalletAddress) {
+		currentWallet = current.WalletAddress
+	}
+
+	timestamp := time.Now().Unix()
+	c.JSON(http.StatusOK, gin.H{
+		"message":       services.PayoutWalletMessage(agent.Username, req.WalletAddress, timestamp),
+		"walletAddress": strings.ToLower(req.WalletAddress),
+		"currentWallet": currentWallet,
+		"timestamp":     timestamp,
+		"cooldownHours": h.Cfg.AgentWalletCooldownHours,
+	})
+}
+
+// BindMyAgentPayoutWallet 主人提交钱包签名，为名下 Agent 绑定提现钱包（仅凭 API Key 无法绑定）
+// 更换已验证的钱包时需同时提交当前钱包对同一消息的签名（currentSignature）
+func (h *Handler) BindMyAgentPayoutWallet(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	agent, ok := h.ownedAgent(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		WalletAddress    string `json:"walletAddress" binding:"required"`
+		Message          string `json:"message" binding:"required"`
+		Signature        string `json:"signature" binding:"required"`
+		CurrentSignature string `json:"currentSignature"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if !common.IsHexAddress(req.WalletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的钱包地址"})
+		return
+	}
+
+	walletService := services.NewAgentWalletService(h.DB, h.Cfg)
+	if err := walletService.ValidatePayoutWalletMessage(agent, req.WalletAddress, req.Message); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	valid, err := verifyEthSignature(req.WalletAddress, req.Message, req.Signature)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature verification failed: " + err.Error()})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+
+	currentWallet := ""
+	if current, err := walletService.GetPayoutWallet(agent.ID); err == nil && current.WalletVerified &&
+		!strings.EqualFold(current.WalletAddress, req.WalletAddress) {
+		if req.CurrentSignature == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature from the current payout wallet is required"})
+			return
+		}
+		valid, err := verifyEthSignature(current.WalletAddress, req.Message, req.CurrentSignature)
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature from the current payout wallet"})
+			return
+		}
+		currentWallet = current.WalletAddress
+	}
+
+	balance, err := walletService.BindPayoutWallet(user.ID, agent.ID, req.WalletAddress, currentWallet, req.Message, req.Signature, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"walletAddress":  balance.WalletAddress,
+		"walletBoundAt":  balance.WalletBoundAt,
+		"withdrawableAt": balance.WithdrawableAt,
+	})
+}
+
+// ownedAgent 获取当前登录用户名下的 Agent（失败时已写入响应）
+func (h *Handler) ownedAgent(c *gin.Context) (*models.Agent, bool) {
+	user := c.MustGet("user").(*models.User)
+	agentID, ok := parseAgentIDParam(c)
+	if !ok {
+		return nil, false
+	}
+
+	var agent models.Agent
+	if err := h.DB.Where("id = ? AND owner_user_id = ?", agentID, user.ID).First(&agent).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent 不存在或不属于你"})
+		return nil, false
+	}
+	return &agent, true
+}
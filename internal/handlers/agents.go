@@ -3,18 +3,24 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GetAgents - 获取 AI 列表
+// agentFollowerWeight Agent 排名中一个关注者相当于的获赞数
+const agentFollowerWeight = 10
+
+// GetAgents - 获取 AI 列表（按 获赞 + 关注者*权重 排名）
 func (h *Handler) GetAgents(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
 
 	var agents []models.Agent
 	h.DB.Where("is_approved = ?", true).
-		Order("total_likes DESC, posts_count DESC").
+		Order(gorm.Expr("total_likes + followers_count * ? DESC, posts_count DESC", agentFollowerWeight)).
 		Limit(limit).
 		Find(&agents)
 
@@ -36,26 +42,46 @@ func (h *Handler) GetAgent(c *gin.Context) {
 		Where("agent_id = ?", agent.ID).
 		Order("hotness_score DESC").
 		Find(&posts)
+	services.NewPostGateService(h.DB, h.Cfg).Redact(posts, c.GetString("wallet_address"))
+
+	// 登录用户返回是否已关注
+	following := false
+	if user, ok := c.Get("user"); ok {
+		var count int64
+		h.DB.Model(&models.Follow{}).Where("user_id = ? AND agent_id = ?", user.(*models.User).ID, agent.ID).Count(&count)
+		following = count > 0
+	}
 
-	c.JSON(http.StatusOK, gin.H{"agent": agent, "posts": posts})
+	c.JSON(http.StatusOK, gin.H{"agent": agent, "posts": posts, "following": following})
 }
 
-// SearchAgents - 搜索 AI
+// SearchAgents - 搜索 AI（用户名和简介全文检索）
 func (h *Handler) SearchAgents(c *gin.Context) {
-	query := c.Query("q")
+	query := strings.TrimSpace(c.Query("q"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
 
 	if query == "" {
 		c.JSON(http.StatusOK, gin.H{"agents": []models.Agent{}})
 		return
 	}
 
-	var agents []models.Agent
-	h.DB.Where("username ILIKE ? AND is_approved = ?", "%"+query+"%", true).
-		Limit(limit).
-		Find(&agents)
+	agents, total, err := services.NewSearchService(h.DB, h.Cfg).SearchAgents(query, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败"})
+		return
+	}
+	for i := range agents {
+		agents[i].Snippet = services.Snippet(agents[i].Bio, query)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"agents": agents, "query": query})
+	c.JSON(http.StatusOK, gin.H{"agents": agents, "total": total, "page": page, "limit": limit, "query": query})
 }
 
 // GetApplicationStatus - 查询申请状态
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 空投 ====================
+
+// GetAirdropSnapshots 获取空投快照列表
+func (h *Handler) GetAirdropSnapshots(c *gin.Context) {
+	airdropService := services.NewAirdropService(h.DB, h.Cfg)
+	snapshots, err := airdropService.GetActiveSnapshots()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取空投快照失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+}
+
+// GetAirdropProof 获取钱包的空投领取证明（MerkleDistributor.claim 参数）
+func (h *Handler) GetAirdropProof(c *gin.Context) {
+	wallet := c.Param("wallet")
+	if !common.IsHexAddress(wallet) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的钱包地址"})
+		return
+	}
+
+	airdropService := services.NewAirdropService(h.DB, h.Cfg)
+	proof, err := airdropService.GetProof(wallet, c.Query("snapshot"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proof)
+}
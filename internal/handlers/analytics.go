@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 管理后台：资金流分析 ====================
+
+// AdminGetAnalytics 获取资金流时间序列
+// 参数：bucket=day|week|month（默认 day），from/to（YYYY-MM-DD，默认最近30天）
+func (h *Handler) AdminGetAnalytics(c *gin.Context) {
+	bucket := c.DefaultQuery("bucket", "day")
+	if bucket != "day" && bucket != "week" && bucket != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bucket 只支持 day、week 或 month"})
+		return
+	}
+
+	fromStr := c.Query("from")
+	if fromStr == "" {
+		fromStr = time.Now().UTC().AddDate(0, 0, -29).Format("2006-01-02")
+	}
+	from, to, err := parseStatementRange(fromStr, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("to") == "" {
+		// 默认包含今天
+		to = time.Now().UTC().AddDate(0, 0, 1)
+	}
+	if to.Sub(from) > 3*366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "时间范围不能超过3年"})
+		return
+	}
+
+	analyticsService := services.NewAnalyticsService(h.DB, h.Cfg)
+	series, err := analyticsService.GetTimeSeries(bucket, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bucket": bucket,
+		"from":   from.Format("2006-01-02"),
+		"to":     to.Format("2006-01-02"),
+		"series": series,
+	})
+}
+
+// AdminRefreshAnalytics 重算指定日期范围的每日汇总（修正历史数据后使用）
+func (h *Handler) AdminRefreshAnalytics(c *gin.Context) {
+	var req struct {
+		From string `json:"from" binding:"required"` // YYYY-MM-DD
+		To   string `json:"to" binding:"required"`   // YYYY-MM-DD（包含）
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	from, to, err := parseStatementRange(req.From, req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analyticsService := services.NewAnalyticsService(h.DB, h.Cfg)
+	if err := analyticsService.RefreshDaily(from, to); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重算失败"})
+		return
+	}
+
+	services.RecordAudit(h.DB, adminActor(c), "analytics.refresh", "analytics", req.From+".."+req.To, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// ==================== 悬赏 ====================
+
+// GetBounties 获取悬赏列表（公开，默认进行中）
+func (h *Handler) GetBounties(c *gin.Context) {
+	status := c.DefaultQuery("status", "open")
+	if status == "all" {
+		status = ""
+	}
+	h.listBounties(c, status, "")
+}
+
+// GetMyBounties 获取我发布的悬赏
+func (h *Handler) GetMyBounties(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+	h.listBounties(c, c.Query("status"), walletAddress)
+}
+
+// AgentGetBounties Agent 获取可回答的悬赏
+func (h *Handler) AgentGetBounties(c *gin.Context) {
+	h.listBounties(c, "open", "")
+}
+
+func (h *Handler) listBounties(c *gin.Context, status string, creatorWallet string) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	bountyService := services.NewBountyService(h.DB, h.Cfg)
+	bounties, total, err := bountyService.ListBounties(status, creatorWallet, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取悬赏失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bounties": bounties,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetBounty 获取悬赏详情（含回答）
+func (h *Handler) GetBounty(c *gin.Context) {
+	id, ok := parseBountyID(c)
+	if !ok {
+		return
+	}
+
+	bountyService := services.NewBountyService(h.DB, h.Cfg)
+	bounty, err := bountyService.GetBounty(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "悬赏不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bounty": bounty})
+}
+
+// CreateBounty 发布悬赏
+func (h *Handler) CreateBounty(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	var req struct {
+		Title          string    `json:"title" binding:"required"`
+		Prompt         string    `json:"prompt" binding:"required"`
+		Category       string    `json:"category"`
+		Amount         string    `json:"amount" binding:"required"` // 字符串避免精度丢失
+		AnswerDeadline time.Time `json:"answerDeadline" binding:"required"`
+		ExpireMode     string    `json:"expireMode"` // refund（默认）/split
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "悬赏金额无效"})
+		return
+	}
+
+	bountyService := services.NewBountyService(h.DB, h.Cfg)
+	bounty, err := bountyService.CreateBounty(walletAddress, services.BountyInput{
+		Title:          req.Title,
+		Prompt:         req.Prompt,
+		Category:       req.Category,
+		Amount:         amount,
+		AnswerDeadline: req.AnswerDeadline,
+		ExpireMode:     req.ExpireMode,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "bounty": bounty})
+}
+
+// AwardBounty 选出获胜回答（多个时平分）
+func (h *Handler) AwardBounty(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+	id, ok := parseBountyID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PostIDs []uint `json:"postIds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	bountyService := services.NewBountyService(h.DB, h.Cfg)
+	bounty, err := bountyService.Award(walletAddress, id, req.PostIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "bounty": bounty})
+}
+
+// CancelBounty 取消悬赏（无人回答时）
+func (h *Handler) CancelBounty(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+	id, ok := parseBountyID(c)
+	if !ok {
+		return
+	}
+
+	bountyService := services.NewBountyService(h.DB, h.Cfg)
+	bounty, err := bountyService.Cancel(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "bounty": bounty})
+}
+
+// parseBountyID 解析路径中的悬赏ID
+func parseBountyID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的悬赏ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// ==================== 奖励活动 ====================
+
+// GetActiveCampaigns 获取进行中的奖励活动（公开）
+func (h *Handler) GetActiveCampaigns(c *gin.Context) {
+	campaignService := services.NewCampaignService(h.DB, h.Cfg)
+	campaigns, err := campaignService.GetActiveCampaigns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取活动失败"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		items = append(items, gin.H{
+			"id":          campaign.ID,
+			"name":        campaign.Name,
+			"description": campaign.Description,
+			"startAt":     campaign.StartAt,
+			"endAt":       campaign.EndAt,
+			"rewardTypes": campaign.RewardTypes,
+			"categories":  campaign.Categories,
+			"agentIds":    campaign.AgentIDs,
+			"multiplier":  campaign.Multiplier,
+			"budget":      campaign.Budget,
+			"remaining":   campaign.Budget.Sub(campaign.Spent),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"campaigns": items})
+}
+
+// campaignRequest 创建/修改活动请求体
+type campaignRequest struct {
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	StartAt     *time.Time `json:"startAt"`
+	EndAt       *time.Time `json:"endAt"`
+	RewardTypes []string   `json:"rewardTypes"`
+	Categories  []string   `json:"categories"`
+	AgentIDs    []uint     `json:"agentIds"`
+	Multiplier  *string    `json:"multiplier"` // 字符串避免精度丢失
+	Budget      *string    `json:"budget"`
+	IsActive    *bool      `json:"isActive"`
+}
+
+// toInput 转换为服务层参数
+func (req *campaignRequest) toInput() (services.CampaignInput, string) {
+	input := services.CampaignInput{
+		Name:        req.Name,
+		Description: req.Description,
+		StartAt:     req.StartAt,
+		EndAt:       req.EndAt,
+		RewardTypes: req.RewardTypes,
+		Categories:  req.Categories,
+		AgentIDs:    req.AgentIDs,
+		IsActive:    req.IsActive,
+	}
+	if req.Multiplier != nil {
+		multiplier, err := decimal.NewFromString(*req.Multiplier)
+		if err != nil {
+			return input, "倍数无效"
+		}
+		input.Multiplier = &multiplier
+	}
+	if req.Budget != nil {
+		budget, err := decimal.NewFromString(*req.Budget)
+		if err != nil {
+			return input, "预算无效"
+		}
+		input.Budget = &budget
+	}
+	return input, ""
+}
+
+// AdminGetCampaigns 获取所有奖励活动
+func (h *Handler) AdminGetCampaigns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	campaignService := services.NewCampaignService(h.DB, h.Cfg)
+	campaigns, total, err := campaignService.ListCampaigns(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取活动失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"campaigns": campaigns,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// AdminCreateCampaign 创建奖励活动
+func (h *Handler) AdminCreateCampaign(c *gin.Context) {
+	var req campaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	input, msg := req.toInput()
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	campaignService := services.NewCampaignService(h.DB, h.Cfg)
+	campaign, err := campaignService.CreateCampaign(input, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "campaign": campaign})
+}
+
+// AdminUpdateCampaign 修改奖励活动
+func (h *Handler) AdminUpdateCampaign(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的活动ID"})
+		return
+	}
+
+	var req campaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	input, msg := req.toInput()
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	campaignService := services.NewCampaignService(h.DB, h.Cfg)
+	campaign, err := campaignService.UpdateCampaign(uint(id), input, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "campaign": campaign})
+}
@@ -8,26 +8,79 @@ import (
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// GetComments - 获取帖子评论
+// GetComments - 获取帖子的顶层评论（sort: new/top，回复通过 thread 接口展开）
 func (h *Handler) GetComments(c *gin.Context) {
 	postID := c.Param("id")
+	sort := c.DefaultQuery("sort", "new")
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	if sort != "top" {
+		sort = "new"
+	}
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+	if cursor != nil && sort == "top" && cursor.Score == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "游标与排序方式不匹配"})
+		return
+	}
+
+	query := h.DB.Where("post_id = ? AND parent_id IS NULL", postID).Scopes(services.PreloadComment)
+	if sort == "top" {
+		if cursor != nil {
+			query = query.Where("(likes_count, created_at, id) < (?, ?, ?)", int(*cursor.Score), *cursor.Time, cursor.ID)
+		}
+		query = query.Order("likes_count DESC, created_at DESC, id DESC")
+	} else {
+		query = query.Scopes(services.KeysetByTime("comments", "created_at", cursor))
+	}
+	if cursor == nil {
+		query = query.Offset(offset)
+	}
 
 	var comments []models.Comment
-	h.DB.Where("post_id = ?", postID).
-		Preload("User").
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&comments)
-
-	c.JSON(http.StatusOK, gin.H{"comments": comments})
+	query.Limit(limit + 1).Find(&comments)
+
+	nextCursor := ""
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[len(comments)-1]
+		next := services.Cursor{Time: &last.CreatedAt, ID: last.ID}
+		if sort == "top" {
+			score := float64(last.LikesCount)
+			next.Score = &score
+		}
+		nextCursor = services.EncodeCursor(next)
+	}
+
+	services.NewCommentService(h.DB, h.Cfg).MarkLiked(comments, viewerID(c))
+
+	c.JSON(http.StatusOK, gin.H{"comments": comments, "sort": sort, "nextCursor": nextCursor})
+}
+
+// GetCommentThread - 获取评论及其全部回复（树形，depth 限制展开层数）
+func (h *Handler) GetCommentThread(c *gin.Context) {
+	commentID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", strconv.Itoa(h.Cfg.CommentMaxDepth)))
+
+	thread, err := services.NewCommentService(h.DB, h.Cfg).
+		Thread(uint(commentID), depth, c.DefaultQuery("sort", "top"), viewerID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "评论不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comment": thread})
 }
 
-// CreateComment - 创建评论
+// CreateComment - 创建评论（带 parentId 时为回复，可附图片或视频）
 func (h *Handler) CreateComment(c *gin.Context) {
 	postID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 	wallet := c.GetString("wallet")
@@ -37,7 +90,10 @@ func (h *Handler) CreateComment(c *gin.Context) {
 	userID := c.GetFloat64("userId")
 
 	var req struct {
-		Content string `json:"content" binding:"required,max=500"`
+		Content  string   `json:"content" binding:"required,max=500"`
+		ParentID *uint    `json:"parentId"`
+		Images   []string `json:"images"`   // 先通过 /upload 上传
+		VideoURL string   `json:"videoUrl"` // 与 images 二选一
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -46,23 +102,22 @@ func (h *Handler) CreateComment(c *gin.Context) {
 	}
 
 	uid := uint(userID)
-	comment := models.Comment{
+	comment, err := services.NewCommentService(h.DB, h.Cfg).Create(services.CommentInput{
 		PostID:        uint(postID),
+		ParentID:      req.ParentID,
 		UserID:        &uid,
 		WalletAddress: wallet,
 		Content:       req.Content,
-	}
-
-	if err := h.DB.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		Images:        req.Images,
+		VideoURL:      req.VideoURL,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 更新帖子评论数
-	h.DB.Model(&models.Post{}).Where("id = ?", postID).UpdateColumn("comments_count", gorm.Expr("comments_count + 1"))
-
 	// 更新热度
-	go UpdateHotness(h.DB, uint(postID))
+	go h.recordActivity(uint(postID), services.ActivityDelta{Comments: 1})
 
 	// 发放评论奖励（异步）
 	go func() {
@@ -74,7 +129,70 @@ func (h *Handler) CreateComment(c *gin.Context) {
 	}()
 
 	// 加载用户信息
-	h.DB.Preload("User").First(&comment, comment.ID)
+	h.DB.Scopes(services.PreloadComment).First(comment, comment.ID)
 
 	c.JSON(http.StatusCreated, gin.H{"comment": comment})
 }
+
+// LikeComment - 点赞评论（重复点赞不报错）
+func (h *Handler) LikeComment(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var comment models.Comment
+	if err := h.DB.First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "评论不存在"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.CommentLike{CommentID: comment.ID, UserID: user.ID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", comment.ID).
+			UpdateColumn("likes_count", gorm.Expr("likes_count + 1")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "点赞失败"})
+		return
+	}
+
+	h.DB.Select("likes_count").First(&comment, comment.ID)
+	c.JSON(http.StatusOK, gin.H{"liked": true, "likesCount": comment.LikesCount})
+}
+
+// UnlikeComment - 取消点赞评论
+func (h *Handler) UnlikeComment(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var comment models.Comment
+	if err := h.DB.First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "评论不存在"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("comment_id = ? AND user_id = ?", comment.ID, user.ID).Delete(&models.CommentLike{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", comment.ID).
+			UpdateColumn("likes_count", gorm.Expr("GREATEST(likes_count - 1, 0)")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消点赞失败"})
+		return
+	}
+
+	h.DB.Select("likes_count").First(&comment, comment.ID)
+	c.JSON(http.StatusOK, gin.H{"liked": false, "likesCount": comment.LikesCount})
+}
+
+// viewerID 当前登录用户 ID（游客为 0）
+func viewerID(c *gin.Context) uint {
+	if user, ok := c.Get("user"); ok {
+		return user.(*models.User).ID
+	}
+	return 0
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// ==================== 手续费报价 ====================
+
+// GetFeeQuote 用户手续费报价（不扣款）
+// 参数：type=tip|withdraw，amount，打赏时需要 postId
+func (h *Handler) GetFeeQuote(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	amount, err := decimal.NewFromString(c.Query("amount"))
+	if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "金额无效"})
+		return
+	}
+
+	input := services.FeeInput{
+		FeeType:     c.DefaultQuery("type", services.FeeTypeTip),
+		PayerType:   "user",
+		PayerWallet: walletAddress,
+		Amount:      amount,
+	}
+	switch input.FeeType {
+	case services.FeeTypeTip:
+		var post models.Post
+		if err := h.DB.First(&post, c.Query("postId")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "帖子不存在"})
+			return
+		}
+		input.AgentID = post.AgentID
+	case services.FeeTypeWithdraw:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type 只支持 tip 或 withdraw"})
+		return
+	}
+
+	feeService := services.NewFeeService(h.DB, h.Cfg)
+	quote, err := feeService.Quote(h.DB, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quote": quote})
+}
+
+// GetAgentFeeQuote Agent提现手续费报价
+func (h *Handler) GetAgentFeeQuote(c *gin.Context) {
+	agent := c.MustGet("agent").(models.Agent)
+
+	amount, err := decimal.NewFromString(c.Query("amount"))
+	if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "金额无效"})
+		return
+	}
+
+	feeService := services.NewFeeService(h.DB, h.Cfg)
+	quote, err := feeService.Quote(h.DB, services.FeeInput{
+		FeeType:   services.FeeTypeWithdraw,
+		PayerType: "agent",
+		PayerID:   agent.ID,
+		AgentID:   agent.ID,
+		Amount:    amount,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quote": quote})
+}
+
+// ==================== 手续费规则（管理员）====================
+
+// feeRuleRequest 创建/修改手续费规则请求体
+type feeRuleRequest struct {
+	FeeType   *string    `json:"feeType"` // tip/withdraw
+	Kind      *string    `json:"kind"`    // tier/agent/staked
+	AgentID   *uint      `json:"agentId"`
+	Threshold *string    `json:"threshold"` // 字符串避免精度丢失
+	Rate      *string    `json:"rate"`
+	Discount  *string    `json:"discount"`
+	MinFee    *string    `json:"minFee"`
+	MaxFee    *string    `json:"maxFee"`
+	StartAt   *time.Time `json:"startAt"`
+	EndAt     *time.Time `json:"endAt"`
+	Note      *string    `json:"note"`
+	IsActive  *bool      `json:"isActive"`
+}
+
+// toInput 转换为服务层参数
+func (req *feeRuleRequest) toInput() (services.FeeRuleInput, string) {
+	input := services.FeeRuleInput{
+		FeeType:  req.FeeType,
+		Kind:     req.Kind,
+		AgentID:  req.AgentID,
+		StartAt:  req.StartAt,
+		EndAt:    req.EndAt,
+		Note:     req.Note,
+		IsActive: req.IsActive,
+	}
+	fields := []struct {
+		value  *string
+		target **decimal.Decimal
+		name   string
+	}{
+		{req.Threshold, &input.Threshold, "threshold"},
+		{req.Rate, &input.Rate, "rate"},
+		{req.Discount, &input.Discount, "discount"},
+		{req.MinFee, &input.MinFee, "minFee"},
+		{req.MaxFee, &input.MaxFee, "maxFee"},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		d, err := decimal.NewFromString(*f.value)
+		if err != nil {
+			return input, f.name + " 无效"
+		}
+		*f.target = &d
+	}
+	return input, ""
+}
+
+// AdminGetFeeRules 获取手续费规则
+func (h *Handler) AdminGetFeeRules(c *gin.Context) {
+	feeService := services.NewFeeService(h.DB, h.Cfg)
+	rules, err := feeService.ListRules(c.Query("feeType"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取手续费规则失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"defaults": gin.H{
+			"tipFeeRate":      h.Cfg.TipFeeRate,
+			"withdrawFeeRate": h.Cfg.WithdrawFeeRate,
+			"volumeDays":      h.Cfg.FeeVolumeDays,
+		},
+	})
+}
+
+// AdminCreateFeeRule 创建手续费规则
+func (h *Handler) AdminCreateFeeRule(c *gin.Context) {
+	var req feeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	input, msg := req.toInput()
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	feeService := services.NewFeeService(h.DB, h.Cfg)
+	rule, err := feeService.CreateRule(input, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "rule": rule})
+}
+
+// AdminUpdateFeeRule 修改手续费规则
+func (h *Handler) AdminUpdateFeeRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则ID"})
+		return
+	}
+
+	var req feeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	input, msg := req.toInput()
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	feeService := services.NewFeeService(h.DB, h.Cfg)
+	rule, err := feeService.UpdateRule(uint(id), input, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "rule": rule})
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== 关注 ====================
+
+// FollowAgent 关注 Agent（重复关注不报错）
+func (h *Handler) FollowAgent(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var agent models.Agent
+	if err := h.DB.Where("username = ? AND is_approved = ?", c.Param("username"), true).First(&agent).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent不存在"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Follow{UserID: user.ID, AgentID: agent.ID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Model(&models.Agent{}).Where("id = ?", agent.ID).
+			UpdateColumn("followers_count", gorm.Expr("followers_count + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).
+			UpdateColumn("following_count", gorm.Expr("following_count + 1")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关注失败"})
+		return
+	}
+
+	h.DB.Select("followers_count").First(&agent, agent.ID)
+	c.JSON(http.StatusOK, gin.H{"following": true, "followersCount": agent.FollowersCount})
+}
+
+// UnfollowAgent 取消关注 Agent
+func (h *Handler) UnfollowAgent(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	var agent models.Agent
+	if err := h.DB.Where("username = ?", c.Param("username")).First(&agent).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent不存在"})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("user_id = ? AND agent_id = ?", user.ID, agent.ID).Delete(&models.Follow{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Model(&models.Agent{}).Where("id = ?", agent.ID).
+			UpdateColumn("followers_count", gorm.Expr("GREATEST(followers_count - 1, 0)")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).
+			UpdateColumn("following_count", gorm.Expr("GREATEST(following_count - 1, 0)")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消关注失败"})
+		return
+	}
+
+	h.DB.Select("followers_count").First(&agent, agent.ID)
+	c.JSON(http.StatusOK, gin.H{"following": false, "followersCount": agent.FollowersCount})
+}
+
+// GetAgentFollowers Agent 的关注者列表（游标分页，最新关注在前）
+func (h *Handler) GetAgentFollowers(c *gin.Context) {
+	limit := followListLimit(c)
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	var agent models.Agent
+	if err := h.DB.Where("username = ?", c.Param("username")).First(&agent).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent不存在"})
+		return
+	}
+
+	type FollowerItem struct {
+		ID            uint      `json:"-"`
+		CreatedAt     time.Time `json:"followedAt"`
+		UserID        uint      `json:"userId"`
+		Nickname      string    `json:"nickname"`
+		Avatar        string    `json:"avatar"`
+		WalletAddress string    `json:"walletAddress"`
+	}
+
+	var items []FollowerItem
+	h.DB.Table("follows").
+		Select("follows.id, follows.created_at, users.id AS user_id, users.nickname, users.avatar, users.wallet_address").
+		Joins("JOIN users ON users.id = follows.user_id").
+		Where("follows.agent_id = ? AND follows.deleted_at IS NULL", agent.ID).
+		Scopes(services.KeysetByTime("follows", "created_at", cursor)).
+		Limit(limit + 1).
+		Scan(&items)
+
+	nextCursor := ""
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[len(items)-1]
+		nextCursor = services.TimeCursor(last.CreatedAt, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"followers":      items,
+		"followersCount": agent.FollowersCount,
+		"nextCursor":     nextCursor,
+	})
+}
+
+// GetMyFollowing 我关注的 Agent（游标分页，最新关注在前）
+func (h *Handler) GetMyFollowing(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	limit := followListLimit(c)
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	var follows []models.Follow
+	h.DB.Where("user_id = ?", user.ID).
+		Scopes(services.KeysetByTime("follows", "created_at", cursor)).
+		Limit(limit + 1).
+		Find(&follows)
+
+	nextCursor := ""
+	if len(follows) > limit {
+		follows = follows[:limit]
+		last := follows[len(follows)-1]
+		nextCursor = services.TimeCursor(last.CreatedAt, last.ID)
+	}
+
+	agentIDs := make([]uint, len(follows))
+	for i, f := range follows {
+		agentIDs[i] = f.AgentID
+	}
+	var agents []models.Agent
+	if len(agentIDs) > 0 {
+		h.DB.Where("id IN ?", agentIDs).Find(&agents)
+	}
+	byID := make(map[uint]models.Agent, len(agents))
+	for _, a := range agents {
+		byID[a.ID] = a
+	}
+
+	type FollowingItem struct {
+		Agent      models.Agent `json:"agent"`
+		FollowedAt time.Time    `json:"followedAt"`
+	}
+	items := make([]FollowingItem, 0, len(follows))
+	for _, f := range follows {
+		if a, ok := byID[f.AgentID]; ok {
+			items = append(items, FollowingItem{Agent: a, FollowedAt: f.CreatedAt})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"following":      items,
+		"followingCount": user.FollowingCount,
+		"nextCursor":     nextCursor,
+	})
+}
+
+// GetFollowingFeed 关注的 Agent 发布的帖子（按发布时间倒序，游标分页）
+func (h *Handler) GetFollowingFeed(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	limit := followListLimit(c)
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
+	var posts []models.Post
+	h.DB.Preload("Agent").Preload("Images").Preload("Videos").
+		Where("agent_id IN (?)", h.DB.Model(&models.Follow{}).Select("agent_id").Where("user_id = ?", user.ID)).
+		Scopes(services.KeysetByTime("posts", "posted_at", cursor)).
+		Limit(limit + 1).
+		Find(&posts)
+
+	nextCursor := ""
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		nextCursor = services.TimeCursor(last.PostedAt, last.ID)
+	}
+
+	services.NewPostGateService(h.DB, h.Cfg).Redact(posts, c.GetString("wallet_address"))
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":      posts,
+		"limit":      limit,
+		"nextCursor": nextCursor,
+	})
+}
+
+// followListLimit 关注相关列表的每页数量
+func followListLimit(c *gin.Context) int {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return limit
+}
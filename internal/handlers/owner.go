@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Agent 主人面板 ====================
+
+// GetMyAgents 获取名下的 Agent（含余额和数据）
+func (h *Handler) GetMyAgents(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+
+	ownerService := services.NewAgentOwnerService(h.DB, h.Cfg)
+	agents, err := ownerService.ListOwnedAgents(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取 Agent 失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"agents": agents})
+}
+
+// RotateAgentAPIKey 更换 Agent 的 API Key
+func (h *Handler) RotateAgentAPIKey(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	agentID, ok := parseAgentIDParam(c)
+	if !ok {
+		return
+	}
+
+	apiKey := generateAPIKey()
+	ownerService := services.NewAgentOwnerService(h.DB, h.Cfg)
+	agent, err := ownerService.RotateAPIKey(user.ID, agentID, apiKey, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"agentId":   agent.ID,
+		"apiKey":    apiKey,
+		"important": "⚠️ The old API key no longer works. Update your agent with the new key.",
+	})
+}
+
+// UpdateMyAgent 暂停/恢复 Agent
+func (h *Handler) UpdateMyAgent(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	agentID, ok := parseAgentIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IsPaused *bool `json:"isPaused" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	ownerService := services.NewAgentOwnerService(h.DB, h.Cfg)
+	agent, err := ownerService.SetPaused(user.ID, agentID, *req.IsPaused, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "agent": agent})
+}
+
+// TransferAgent 将 Agent 转让给其他用户
+func (h *Handler) TransferAgent(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	agentID, ok := parseAgentIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ToWallet string `json:"toWallet" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if !common.IsHexAddress(req.ToWallet) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的钱包地址"})
+		return
+	}
+
+	ownerService := services.NewAgentOwnerService(h.DB, h.Cfg)
+	agent, err := ownerService.TransferOwnership(user.ID, agentID, req.ToWallet, generateAPIKey(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"agentId": agent.ID,
+		"message": "Ownership transferred. The new owner must rotate the API key and re-bind the payout wallet.",
+	})
+}
+
+// GetAgentOwnerLogs 获取 Agent 的主人操作记录
+func (h *Handler) GetAgentOwnerLogs(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	agentID, ok := parseAgentIDParam(c)
+	if !ok {
+		return
+	}
+
+	ownerService := services.NewAgentOwnerService(h.DB, h.Cfg)
+	logs, err := ownerService.GetOwnerLogs(user.ID, agentID, 50)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"logs": logs})
+}
+
+// parseAgentIDParam 解析路径中的 Agent ID
+func parseAgentIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 Agent ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
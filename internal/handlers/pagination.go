@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// parseCursor 解析 cursor 参数（未传时返回 nil，走原有 page/offset 分页）
+func parseCursor(c *gin.Context) (*services.Cursor, bool) {
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, true
+	}
+	cursor, err := services.DecodeCursor(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的游标"})
+		return nil, false
+	}
+	return cursor, true
+}
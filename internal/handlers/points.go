@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -184,7 +185,7 @@ func (h *Handler) TipPost(c *gin.Context) {
 	tx.Commit()
 
 	// 更新热度（打赏权重高，对热度影响大）
-	go UpdateHotness(h.DB, post.ID)
+	go h.recordActivity(post.ID, services.ActivityDelta{Tips: 1})
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":        true,
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 帖子编辑与删除 ====================
+
+// AgentUpdatePost Agent 编辑自己的帖子（发布后一段时间内，每次编辑保留历史版本）
+// 未传的字段保持不变；未传 topics 时保留原话题并补充新正文中提取的话题
+func (h *Handler) AgentUpdatePost(c *gin.Context) {
+	agentID := c.GetUint("agentID")
+	postID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	var req struct {
+		Content *string  `json:"content" binding:"omitempty,min=1,max=200"`
+		Context *string  `json:"context" binding:"omitempty,max=100"`
+		Topics  []string `json:"topics"`
+		Preview *string  `json:"preview"` // 付费/持仓帖子的预览
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var post models.Post
+	if err := h.DB.Where("id = ? AND agent_id = ?", postID, agentID).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	edit := services.PostEdit{Content: post.Content, Context: post.Context, Preview: req.Preview}
+	if req.Content != nil {
+		edit.Content = *req.Content
+	}
+	if req.Context != nil {
+		edit.Context = *req.Context
+	}
+	topics := req.Topics
+	if topics == nil && post.Topics != "" {
+		topics = strings.Split(post.Topics, ",")
+	}
+	edit.Topics = strings.Join(buildTopics(topics, edit.Content), ",")
+
+	updated, err := services.NewPostEditService(h.DB, h.Cfg).Edit(agentID, uint(postID), edit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.DB.Preload("Agent").Preload("Images").Preload("Videos").First(&post, updated.ID)
+	c.JSON(http.StatusOK, gin.H{"post": post})
+}
+
+// AgentDeletePost Agent 删除自己的帖子（软删除）
+func (h *Handler) AgentDeletePost(c *gin.Context) {
+	agentID := c.GetUint("agentID")
+	postID, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err := services.NewPostEditService(h.DB, h.Cfg).Delete(agentID, uint(postID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+// GetPostRevisions 帖子的编辑历史（未解锁的付费/持仓帖子隐藏历史正文）
+func (h *Handler) GetPostRevisions(c *gin.Context) {
+	var post models.Post
+	if err := h.DB.First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "帖子不存在"})
+		return
+	}
+
+	revisions, err := services.NewPostEditService(h.DB, h.Cfg).Revisions(post.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取编辑历史失败"})
+		return
+	}
+
+	services.NewPostGateService(h.DB, h.Cfg).RedactOne(&post, c.GetString("wallet_address"))
+	locked := post.Locked && post.GateScope != "media"
+	if locked {
+		for i := range revisions {
+			revisions[i].Content = ""
+			revisions[i].Context = ""
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+		"edited":    post.Edited,
+		"locked":    locked,
+	})
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"crypto/rand"
 	"encoding/hex"
-	"math"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -14,6 +13,7 @@ import (
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -66,7 +66,30 @@ func extractTopics(content string) []string {
 	return result
 }
 
-// GetPosts - 获取帖子列表
+// buildTopics 合并指定的话题和正文中提取的话题（最多 5 个）
+func buildTopics(requested []string, content string) []string {
+	allTopics := make(map[string]bool)
+	for _, t := range requested {
+		allTopics[strings.TrimSpace(t)] = true
+	}
+	for _, t := range extractTopics(content) {
+		allTopics[t] = true
+	}
+	topicList := make([]string, 0, len(allTopics))
+	for t := range allTopics {
+		if t != "" {
+			topicList = append(topicList, t)
+		}
+	}
+	if len(topicList) > 5 {
+		topicList = topicList[:5]
+	}
+	return topicList
+}
+
+// GetPosts - 获取帖子列表（sort: hot/new/top/rising/controversial/foryou）
+// top、controversial 可用 window=day/week/month/all 限定发布时间范围
+// 支持两种分页：page/limit（返回 total），或 cursor（上一页返回的 nextCursor，不统计总数）
 func (h *Handler) GetPosts(c *gin.Context) {
 	category := c.DefaultQuery("category", "all")
 	topic := c.Query("topic")
@@ -74,56 +97,184 @@ func (h *Handler) GetPosts(c *gin.Context) {
 	agentUsername := c.Query("agentUsername")
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
 	offset := (page - 1) * limit
 
-	// 构建基础查询条件
-	baseQuery := h.DB.Model(&models.Post{})
-	if category != "all" {
-		baseQuery = baseQuery.Where("category = ?", category)
+	// 为你推荐：每次返回一批未推荐过的帖子，不分页；游客退回热门
+	if sort == "foryou" {
+		if user, ok := c.Get("user"); ok {
+			h.getForYouPosts(c, user.(*models.User), limit)
+			return
+		}
+		sort = "hot"
 	}
-	if topic != "" {
-		baseQuery = baseQuery.Where("topics ILIKE ?", "%"+topic+"%")
+
+	scoreColumn, ok := postSortColumns[sort]
+	if !ok && sort != "new" {
+		sort, scoreColumn = "hot", postSortColumns["hot"]
 	}
-	if agentUsername != "" {
-		var agent models.Agent
-		if err := h.DB.Where("username = ?", agentUsername).First(&agent).Error; err == nil {
-			baseQuery = baseQuery.Where("agent_id = ?", agent.ID)
+	var since time.Time
+	if sort == "top" || sort == "controversial" {
+		if since, ok = postWindowSince(c.DefaultQuery("window", "all")); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "时间范围无效，可选 day/week/month/all"})
+			return
 		}
 	}
 
-	// 统计总数
-	var total int64
-	baseQuery.Count(&total)
-
-	// 查询帖子（带 Preload）
-	var posts []models.Post
-	query := h.DB.Preload("Agent").Preload("Images").Preload("Videos")
-	if category != "all" {
-		query = query.Where("category = ?", category)
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
 	}
-	if topic != "" {
-		query = query.Where("topics ILIKE ?", "%"+topic+"%")
+	if cursor != nil && sort != "new" && cursor.Score == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "游标与排序方式不匹配"})
+		return
 	}
+
+	// 构建基础查询条件
+	var agentID uint
 	if agentUsername != "" {
 		var agent models.Agent
 		if err := h.DB.Where("username = ?", agentUsername).First(&agent).Error; err == nil {
-			query = query.Where("agent_id = ?", agent.ID)
+			agentID = agent.ID
+		}
+	}
+	filter := func(db *gorm.DB) *gorm.DB {
+		if category != "all" {
+			db = db.Where("category = ?", category)
+		}
+		if topic != "" {
+			db = db.Where("topics ILIKE ?", "%"+topic+"%")
+		}
+		if agentID > 0 {
+			db = db.Where("agent_id = ?", agentID)
 		}
+		if !since.IsZero() {
+			db = db.Where("posted_at >= ?", since)
+		}
+		switch sort {
+		case "rising":
+			db = db.Where("rising_score > 0")
+		case "controversial":
+			db = db.Where("controversy_score > 0")
+		}
+		return db
 	}
 
+	// 查询帖子（带 Preload），多取一条判断是否还有下一页
+	var posts []models.Post
+	query := h.DB.Preload("Agent").Preload("Images").Preload("Videos").Scopes(filter)
 	if sort == "new" {
-		query = query.Order("posted_at DESC")
+		if cursor != nil {
+			query = query.Where("(posted_at, id) < (?, ?)", *cursor.Time, cursor.ID)
+		}
+		query = query.Order("posted_at DESC, id DESC")
 	} else {
-		query = query.Order("hotness_score DESC, posted_at DESC")
+		if cursor != nil {
+			query = query.Where("("+scoreColumn+", posted_at, id) < (?, ?, ?)", *cursor.Score, *cursor.Time, cursor.ID)
+		}
+		query = query.Order(scoreColumn + " DESC, posted_at DESC, id DESC")
+	}
+	if cursor == nil {
+		query = query.Offset(offset)
+	}
+	query.Limit(limit + 1).Find(&posts)
+
+	nextCursor := ""
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		next := services.Cursor{Time: &last.PostedAt, ID: last.ID}
+		if sort != "new" {
+			score := postSortScore(&last, sort)
+			next.Score = &score
+		}
+		nextCursor = services.EncodeCursor(next)
 	}
-	query.Offset(offset).Limit(limit).Find(&posts)
+
+	// 未解锁的付费/持仓帖子只返回预览
+	services.NewPostGateService(h.DB, h.Cfg).Redact(posts, c.GetString("wallet_address"))
+
+	if cursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"posts":      posts,
+			"limit":      limit,
+			"sort":       sort,
+			"nextCursor": nextCursor,
+		})
+		return
+	}
+
+	// 统计总数（仅 page 分页）
+	var total int64
+	h.DB.Model(&models.Post{}).Scopes(filter).Count(&total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":      posts,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"sort":       sort,
+		"nextCursor": nextCursor,
+	})
+}
+
+// postSortColumns 各排序方式使用的分数列（均有 (分数, posted_at, id) 索引，由 HotnessService 维护）
+var postSortColumns = map[string]string{
+	"hot":           "hotness_score",
+	"top":           "engagement_score",
+	"rising":        "rising_score",
+	"controversial": "controversy_score",
+}
+
+// postSortScore 帖子在指定排序方式下的分数（用于生成游标）
+func postSortScore(post *models.Post, sort string) float64 {
+	switch sort {
+	case "top":
+		return post.EngagementScore
+	case "rising":
+		return post.RisingScore
+	case "controversial":
+		return post.ControversyScore
+	default:
+		return post.HotnessScore
+	}
+}
+
+// postWindowSince 时间范围对应的起始时间（all 返回零值，表示不限）
+func postWindowSince(window string) (time.Time, bool) {
+	now := time.Now()
+	switch window {
+	case "day":
+		return now.AddDate(0, 0, -1), true
+	case "week":
+		return now.AddDate(0, 0, -7), true
+	case "month":
+		return now.AddDate(0, -1, 0), true
+	case "all":
+		return time.Time{}, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// getForYouPosts 个性化推荐
+func (h *Handler) getForYouPosts(c *gin.Context, user *models.User, limit int) {
+	posts, err := services.NewFeedService(h.DB, h.Cfg).ForYou(user, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取推荐失败"})
+		return
+	}
+	services.NewPostGateService(h.DB, h.Cfg).Redact(posts, c.GetString("wallet_address"))
 
 	c.JSON(http.StatusOK, gin.H{
 		"posts": posts,
-		"total": total,
-		"page":  page,
 		"limit": limit,
-		"sort":  sort,
+		"sort":  "foryou",
 	})
 }
 
@@ -135,32 +286,84 @@ func (h *Handler) GetPost(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
+	services.NewPostGateService(h.DB, h.Cfg).RedactOne(&post, c.GetString("wallet_address"))
 	c.JSON(http.StatusOK, gin.H{"post": post})
 }
 
-// SearchPosts - 搜索帖子
+// SearchPosts - 搜索帖子（全文检索，按相关度与热度排序）
 func (h *Handler) SearchPosts(c *gin.Context) {
-	query := c.Query("q")
+	query := strings.TrimSpace(c.Query("q"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
 	if query == "" {
 		c.JSON(http.StatusOK, gin.H{"posts": []models.Post{}, "count": 0})
 		return
 	}
-	var posts []models.Post
-	h.DB.Preload("Agent").Preload("Images").Preload("Videos").
-		Where("content ILIKE ? OR topics ILIKE ?", "%"+query+"%", "%"+query+"%").
-		Order("hotness_score DESC").Limit(limit).Find(&posts)
-	c.JSON(http.StatusOK, gin.H{"posts": posts, "count": len(posts), "query": query})
-}
 
-// RandomPost - 随机一条
-func (h *Handler) RandomPost(c *gin.Context) {
-	var post models.Post
-	if err := h.DB.Preload("Agent").Preload("Images").Preload("Videos").Order("RANDOM()").First(&post).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No posts found"})
+	input := services.PostSearchInput{
+		Query:    query,
+		Category: c.Query("category"),
+		Media:    c.Query("media"), // image/video/text
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}
+	if id, err := strconv.ParseUint(c.Query("agentId"), 10, 32); err == nil {
+		input.AgentID = uint(id)
+	}
+	if username := c.Query("agentUsername"); username != "" {
+		var agent models.Agent
+		if err := h.DB.Where("username = ?", username).First(&agent).Error; err != nil {
+			c.JSON(http.StatusOK, gin.H{"posts": []models.Post{}, "count": 0, "total": 0, "query": query})
+			return
+		}
+		input.AgentID = agent.ID
+	}
+	if from := c.Query("from"); from != "" {
+		t, _, err := parseStatementTime(from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from 格式错误，应为 YYYY-MM-DD 或 RFC3339"})
+			return
+		}
+		input.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, dateOnly, err := parseStatementTime(to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to 格式错误，应为 YYYY-MM-DD 或 RFC3339"})
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		input.To = &t
+	}
+
+	posts, total, err := services.NewSearchService(h.DB, h.Cfg).SearchPosts(input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"post": post})
+
+	// 先按查看者隐藏未解锁内容，再从可见内容生成摘要
+	services.NewPostGateService(h.DB, h.Cfg).Redact(posts, c.GetString("wallet_address"))
+	for i := range posts {
+		posts[i].Snippet = services.Snippet(posts[i].Content, query)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": posts,
+		"count": len(posts),
+		"total": total,
+		"page":  page,
+		"limit": limit,
+		"query": query,
+	})
 }
 
 // GetTopics - 获取热门话题
@@ -228,14 +431,14 @@ func (h *Handler) LikePost(c *gin.Context) {
 		h.DB.Delete(&existingLike)
 		h.DB.Model(&models.Post{}).Where("id = ?", postID).UpdateColumn("likes_count", gorm.Expr("likes_count - 1"))
 		// 更新热度
-		go UpdateHotness(h.DB, uint(postID))
+		go h.recordActivity(uint(postID), services.ActivityDelta{Likes: -1})
 		c.JSON(http.StatusOK, gin.H{"liked": false})
 	} else {
 		like := models.Like{PostID: uint(postID), WalletAddress: wallet}
 		h.DB.Create(&like)
 		h.DB.Model(&models.Post{}).Where("id = ?", postID).UpdateColumn("likes_count", gorm.Expr("likes_count + 1"))
 		// 更新热度
-		go UpdateHotness(h.DB, uint(postID))
+		go h.recordActivity(uint(postID), services.ActivityDelta{Likes: 1})
 		
 		// 发放点赞奖励（异步）
 		go func() {
@@ -336,12 +539,53 @@ func (h *Handler) AgentCreatePost(c *gin.Context) {
 		Topics   []string `json:"topics"`
 		Images   []string `json:"images"`
 		VideoURL string   `json:"videoUrl"`
+		BountyID *uint    `json:"bountyId"` // 可选，回答悬赏
+		// 可选，付费/持仓解锁
+		Gate        string `json:"gate"`        // pay/hold
+		GateScope   string `json:"gateScope"`   // media/all（默认 all）
+		UnlockPrice string `json:"unlockPrice"` // gate=pay 时的价格
+		HoldAmount  string `json:"holdAmount"`  // gate=hold 时的持仓门槛
+		Preview     string `json:"preview"`     // 未解锁时显示的预览（默认截取正文）
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	gate := services.GateInput{GateType: req.Gate, GateScope: req.GateScope, Preview: req.Preview}
+	if req.Gate != "" {
+		if req.BountyID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bounty answers cannot be gated"})
+			return
+		}
+		var err error
+		if req.UnlockPrice != "" {
+			if gate.UnlockPrice, err = decimal.NewFromString(req.UnlockPrice); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unlockPrice"})
+				return
+			}
+		}
+		if req.HoldAmount != "" {
+			if gate.HoldAmount, err = decimal.NewFromString(req.HoldAmount); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holdAmount"})
+				return
+			}
+		}
+		if gate, err = services.NormalizeGate(req.Content, gate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// 回答悬赏时先检查，避免消耗 Nonce 后才失败
+	bountyService := services.NewBountyService(h.DB, h.Cfg)
+	if req.BountyID != nil {
+		if _, err := bountyService.CheckAnswerable(*req.BountyID, agentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// 验证 Nonce
 	var postNonce models.PostNonce
 	err := h.DB.Where("nonce = ? AND agent_id = ? AND used = ? AND expires_at > ?",
@@ -368,22 +612,7 @@ func (h *Handler) AgentCreatePost(c *gin.Context) {
 	if req.Category == "" {
 		req.Category = "funny"
 	}
-	allTopics := make(map[string]bool)
-	for _, t := range req.Topics {
-		allTopics[strings.TrimSpace(t)] = true
-	}
-	for _, t := range extractTopics(req.Content) {
-		allTopics[t] = true
-	}
-	topicList := make([]string, 0, len(allTopics))
-	for t := range allTopics {
-		if t != "" {
-			topicList = append(topicList, t)
-		}
-	}
-	if len(topicList) > 5 {
-		topicList = topicList[:5]
-	}
+	topicList := buildTopics(req.Topics, req.Content)
 	post := models.Post{
 		PostID:   uuid.New().String(),
 		Content:  req.Content,
@@ -392,8 +621,27 @@ func (h *Handler) AgentCreatePost(c *gin.Context) {
 		Topics:   strings.Join(topicList, ","),
 		AgentID:  agentID,
 		PostedAt: time.Now(),
-	}
-	if err := h.DB.Create(&post).Error; err != nil {
+		GateType:    gate.GateType,
+		GateScope:   gate.GateScope,
+		UnlockPrice: gate.UnlockPrice,
+		HoldAmount:  gate.HoldAmount,
+		Preview:     gate.Preview,
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if req.BountyID != nil {
+			post.BountyID = req.BountyID
+			return bountyService.SubmitAnswer(tx, *req.BountyID, agentID, post.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		if req.BountyID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
@@ -410,6 +658,9 @@ func (h *Handler) AgentCreatePost(c *gin.Context) {
 	}
 	h.DB.Model(&models.Agent{}).Where("id = ?", agentID).UpdateColumn("posts_count", gorm.Expr("posts_count + 1"))
 	
+	// 立即建立搜索索引（失败时由后台任务补建）
+	services.NewSearchService(h.DB, h.Cfg).IndexPost(&post)
+	
 	// 增加速率限制计数
 	h.incrementRateLimit(agentID)
 	
@@ -422,46 +673,11 @@ func (h *Handler) AgentCreatePost(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"post": post, "topics": topicList})
 }
 
-// UpdateHotness - 更新热度分数
-// 算法设计（类似 Hacker News / Reddit 热度算法）：
-// 
-// 权重设计：
-// - 点赞: 1分 (最容易获得，门槛低)
-// - 评论: 3分 (需要用户花时间写内容，表示更高参与度)
-// - 打赏: 10分 (需要花费积分，表示最高认可)
-//
-// 时间衰减：
-// - gravity = 1.5 (衰减系数，越大衰减越快)
-// - 使用 (小时数 + 2) 避免新帖子分母过小
-//
-// 公式: score = (likes + comments*3 + tips*10) / (hours + 2)^1.5
-//
-// 这样设计的好处：
-// 1. 新帖子有机会展示（时间短，分母小）
-// 2. 高互动帖子能保持热度（分子大）
-// 3. 打赏权重高，激励优质内容
-// 4. 随时间自然衰减，保持内容新鲜
-func UpdateHotness(db *gorm.DB, postID uint) {
-	var post models.Post
-	if err := db.First(&post, postID).Error; err != nil {
-		return
-	}
-	
-	hoursSincePost := time.Since(post.PostedAt).Hours()
-	if hoursSincePost < 0 {
-		hoursSincePost = 0
-	}
-	
-	// 权重：点赞1 + 评论3 + 打赏10
-	engagementScore := float64(post.LikesCount) + float64(post.CommentsCount)*3 + float64(post.TipsCount)*10
-	
-	// 时间衰减系数
-	gravity := 1.5
-	
-	// 计算热度分数
-	score := engagementScore / math.Pow(hoursSincePost+2, gravity)
-	
-	db.Model(&post).Update("hotness_score", score)
+// recordActivity 互动后记入小时汇总并立即重算帖子热度等排序分数（算法见 services.HotnessService）
+func (h *Handler) recordActivity(postID uint, delta services.ActivityDelta) {
+	hotness := services.NewHotnessService(h.DB, h.Cfg)
+	hotness.RecordActivity(postID, delta)
+	hotness.UpdatePost(postID)
 }
 
 // GetRandomPost - 获取随机帖子
@@ -472,6 +688,7 @@ func (h *Handler) GetRandomPost(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No posts found"})
 		return
 	}
+	services.NewPostGateService(h.DB, h.Cfg).RedactOne(&post, c.GetString("wallet_address"))
 	c.JSON(http.StatusOK, gin.H{"post": post})
 }
 
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 储备金证明 ====================
+
+// GetProofOfReserves 获取最新储备金证明（公开）
+func (h *Handler) GetProofOfReserves(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("history", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	reserveService := services.NewReserveService(h.DB, h.Cfg)
+	latest, err := reserveService.GetLatestSnapshot()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	history, _, err := reserveService.GetSnapshots(limit, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取储备金证明失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"latest":  latest,
+		"history": history,
+		"verification": gin.H{
+			"leaf": "keccak256(utf8(accountKey) ++ salt ++ uint256(balanceRaw))",
+			"node": "keccak256(leftHash ++ uint256(leftSum) ++ rightHash ++ uint256(rightSum)), sum = leftSum + rightSum",
+			"root": "the final hash must equal merkleRoot and the final sum must equal totalLiabilities",
+		},
+	})
+}
+
+// GetMyReserveProof 获取当前用户余额的包含证明
+func (h *Handler) GetMyReserveProof(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	reserveService := services.NewReserveService(h.DB, h.Cfg)
+	proof, err := reserveService.GetAccountProof("user", walletAddress)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proof)
+}
+
+// GetAgentReserveProof 获取当前Agent余额的包含证明
+func (h *Handler) GetAgentReserveProof(c *gin.Context) {
+	agentID := c.GetUint("agentID")
+
+	reserveService := services.NewReserveService(h.DB, h.Cfg)
+	proof, err := reserveService.GetAccountProof("agent", fmt.Sprint(agentID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proof)
+}
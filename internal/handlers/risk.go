@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 反女巫风控（管理员）====================
+
+// AdminGetRiskClusters 获取共用IP/设备的可疑钱包簇
+func (h *Handler) AdminGetRiskClusters(c *gin.Context) {
+	kind := c.Query("kind") // ip/device，为空则全部
+	minSize, _ := strconv.Atoi(c.DefaultQuery("minSize", "2"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	riskService := services.NewRiskService(h.DB, h.Cfg)
+	clusters, err := riskService.GetClusters(kind, minSize, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取风控数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clusters": clusters})
+}
+
+// AdminGetWalletRisk 查看单个钱包的风险评分
+func (h *Handler) AdminGetWalletRisk(c *gin.Context) {
+	wallet := c.Param("wallet")
+
+	riskService := services.NewRiskService(h.DB, h.Cfg)
+	profile, err := riskService.Evaluate(wallet)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "评分失败"})
+		return
+	}
+	fingerprints, _ := riskService.GetFingerprints(wallet)
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile":      profile,
+		"fingerprints": fingerprints,
+	})
+}
+
+// AdminGetDeferredRewards 获取待审核奖励
+func (h *Handler) AdminGetDeferredRewards(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	rewards, total, err := rewardService.GetDeferredRewards(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待审核奖励失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rewards": rewards,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// AdminReviewReward 审核延迟发放的奖励（release/reject）
+func (h *Handler) AdminReviewReward(c *gin.Context) {
+	rewardID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=release reject"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	note := "reviewed by " + c.GetString("admin_wallet")
+	if req.Note != "" {
+		note += ": " + req.Note
+	}
+
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	if req.Action == "release" {
+		reward, err := rewardService.ReleaseDeferredReward(uint(rewardID), note, adminActor(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "reward": reward})
+		return
+	}
+
+	reward, err := rewardService.RejectDeferredReward(uint(rewardID), note, adminActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "reward": reward})
+}
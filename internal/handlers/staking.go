@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// ==================== Agent 质押 ====================
+
+// GetMyStakes 获取我的质押仓位
+func (h *Handler) GetMyStakes(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	stakingService := services.NewStakingService(h.DB, h.Cfg)
+	// 先退回冷却结束的仓位
+	stakingService.ReleaseUnbonded(walletAddress)
+
+	stakes, err := stakingService.ListStakes(walletAddress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取质押失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stakes": stakes})
+}
+
+// CreateStake 质押到 Agent
+func (h *Handler) CreateStake(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	var req struct {
+		AgentID uint   `json:"agentId" binding:"required"`
+		Amount  string `json:"amount" binding:"required"` // 字符串避免精度丢失
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "质押金额无效"})
+		return
+	}
+
+	stakingService := services.NewStakingService(h.DB, h.Cfg)
+	stake, err := stakingService.Stake(walletAddress, req.AgentID, amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "stake": stake})
+}
+
+// UnstakeStake 申请解除质押
+func (h *Handler) UnstakeStake(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的质押ID"})
+		return
+	}
+
+	stakingService := services.NewStakingService(h.DB, h.Cfg)
+	stake, err := stakingService.Unstake(walletAddress, uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "stake": stake})
+}
+
+// GetStakeRewards 获取质押分红记录
+func (h *Handler) GetStakeRewards(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	stakingService := services.NewStakingService(h.DB, h.Cfg)
+	rewards, total, err := stakingService.GetRewards(walletAddress, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分红记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rewards": rewards,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetAgentStaking 获取 Agent 的质押概况（公开）
+func (h *Handler) GetAgentStaking(c *gin.Context) {
+	var agent models.Agent
+	if err := h.DB.Where("username = ?", c.Param("username")).First(&agent).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent not found"})
+		return
+	}
+
+	stakingService := services.NewStakingService(h.DB, h.Cfg)
+	info, err := stakingService.GetAgentStaking(agent.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取质押信息失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"username": agent.Username, "staking": info})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 账单导出 ====================
+
+// GetTokenStatement 导出用户账单
+func (h *Handler) GetTokenStatement(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	h.writeStatement(c, "user", 0, walletAddress)
+}
+
+// GetAgentTokenStatement 导出Agent账单
+func (h *Handler) GetAgentTokenStatement(c *gin.Context) {
+	h.writeStatement(c, "agent", c.GetUint("agentID"), "")
+}
+
+// writeStatement 按 format 流式输出账单
+func (h *Handler) writeStatement(c *gin.Context, accountType string, accountID uint, walletAddress string) {
+	from, to, err := parseStatementRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只支持 csv 或 json"})
+		return
+	}
+
+	statementService := services.NewStatementService(h.DB, h.Cfg)
+	opening, err := statementService.OpeningBalance(accountType, accountID, walletAddress, from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成账单失败"})
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%s-%s", from.Format("20060102"), to.Format("20060102"))
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		c.Status(http.StatusOK)
+
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"time", "type", "amount", "balance", "reference", "counterparty", "source_id"})
+		w.Write([]string{from.Format(time.RFC3339), "opening_balance", "0", opening.String(), "", "", ""})
+
+		count := 0
+		closing, err := statementService.Stream(accountType, accountID, walletAddress, from, to, opening, func(e services.StatementEntry) error {
+			w.Write([]string{
+				e.Time.UTC().Format(time.RFC3339),
+				e.Type,
+				e.Amount.String(),
+				e.Balance.String(),
+				e.Reference,
+				e.Counterparty,
+				fmt.Sprint(e.SourceID),
+			})
+			count++
+			if count%500 == 0 {
+				w.Flush()
+				c.Writer.Flush()
+			}
+			return w.Error()
+		})
+		if err != nil {
+			// 已开始输出，只能记录错误行
+			w.Write([]string{time.Now().UTC().Format(time.RFC3339), "error", "", "", err.Error(), "", ""})
+		} else {
+			w.Write([]string{to.Format(time.RFC3339), "closing_balance", "0", closing.String(), "", "", ""})
+		}
+		w.Flush()
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	header, _ := json.Marshal(gin.H{
+		"accountType":    accountType,
+		"from":           from,
+		"to":             to,
+		"openingBalance": opening,
+	})
+	// 去掉结尾的 }，继续追加 entries 数组
+	c.Writer.Write(header[:len(header)-1])
+	c.Writer.Write([]byte(`,"entries":[`))
+
+	count := 0
+	closing, err := statementService.Stream(accountType, accountID, walletAddress, from, to, opening, func(e services.StatementEntry) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			c.Writer.Write([]byte(","))
+		}
+		c.Writer.Write(data)
+		count++
+		if count%500 == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+
+	footer := gin.H{"count": count, "closingBalance": closing}
+	if err != nil {
+		footer["error"] = err.Error()
+	}
+	tail, _ := json.Marshal(footer)
+	c.Writer.Write([]byte("],"))
+	c.Writer.Write(tail[1:])
+}
+
+// parseStatementRange 解析账单时间范围（支持 YYYY-MM-DD 或 RFC3339，默认今年至今）
+// 只有日期时 to 包含当天
+func parseStatementRange(fromStr string, toStr string) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	to := now
+
+	if fromStr != "" {
+		t, _, err := parseStatementTime(fromStr)
+		if err != nil {
+			return from, to, fmt.Errorf("from 格式错误，应为 YYYY-MM-DD 或 RFC3339")
+		}
+		from = t
+	}
+	if toStr != "" {
+		t, dateOnly, err := parseStatementTime(toStr)
+		if err != nil {
+			return from, to, fmt.Errorf("to 格式错误，应为 YYYY-MM-DD 或 RFC3339")
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+
+	if !to.After(from) {
+		return from, to, fmt.Errorf("to 必须晚于 from")
+	}
+	return from, to, nil
+}
+
+func parseStatementTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
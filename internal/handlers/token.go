@@ -3,11 +3,13 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // ==================== 充值相关 ====================
@@ -60,18 +62,44 @@ func (h *Handler) GetDepositHistory(c *gin.Context) {
 	}
 	offset := (page - 1) * limit
 
-	var deposits []models.Deposit
-	var total int64
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
 
+	var deposits []models.Deposit
 	query := h.DB.Model(&models.Deposit{}).Where("wallet_address = ?", walletAddress)
+	list := query.Session(&gorm.Session{}).Scopes(services.KeysetByTime("deposits", "created_at", cursor))
+	if cursor == nil {
+		list = list.Offset(offset)
+	}
+	list.Limit(limit + 1).Find(&deposits)
+
+	nextCursor := ""
+	if len(deposits) > limit {
+		deposits = deposits[:limit]
+		last := deposits[len(deposits)-1]
+		nextCursor = services.TimeCursor(last.CreatedAt, last.ID)
+	}
+
+	if cursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"deposits":   deposits,
+			"limit":      limit,
+			"nextCursor": nextCursor,
+		})
+		return
+	}
+
+	var total int64
 	query.Count(&total)
-	query.Order("created_at desc").Limit(limit).Offset(offset).Find(&deposits)
 
 	c.JSON(http.StatusOK, gin.H{
-		"deposits": deposits,
-		"total":    total,
-		"page":     page,
-		"limit":    limit,
+		"deposits":   deposits,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"nextCursor": nextCursor,
 	})
 }
 
@@ -91,20 +119,34 @@ func (h *Handler) GetTokenBalance(c *gin.Context) {
 		return
 	}
 
+	// 释放已到期的解锁奖励
+	vestingService := services.NewVestingService(h.DB, h.Cfg)
+	vestingService.ReleaseVested("user", 0, walletAddress)
+
 	balance, err := tokenService.GetUserBalance(walletAddress)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取余额失败"})
 		return
 	}
 
+	schedule, err := vestingService.GetSchedule("user", 0, walletAddress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取解锁计划失败"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"balance":        balance.Balance,
-		"lockedBalance":  balance.LockedBalance,
-		"totalDeposited": balance.TotalDeposited,
-		"totalWithdrawn": balance.TotalWithdrawn,
-		"totalTipped":    balance.TotalTipped,
-		"totalReceived":  balance.TotalReceived,
-		"totalRewards":   balance.TotalRewards,
+		"balance":         balance.Balance,
+		"lockedBalance":   balance.LockedBalance,
+		"vestingBalance":  balance.VestingBalance,
+		"stakedBalance":   balance.StakedBalance,
+		"escrowBalance":   balance.EscrowBalance,
+		"vestingSchedule": schedule,
+		"totalDeposited":  balance.TotalDeposited,
+		"totalWithdrawn":  balance.TotalWithdrawn,
+		"totalTipped":     balance.TotalTipped,
+		"totalReceived":   balance.TotalReceived,
+		"totalRewards":    balance.TotalRewards,
 	})
 }
 
@@ -124,21 +166,33 @@ func (h *Handler) GetAgentTokenBalance(c *gin.Context) {
 		return
 	}
 
+	// 释放已到期的解锁奖励
+	vestingService := services.NewVestingService(h.DB, h.Cfg)
+	vestingService.ReleaseVested("agent", agent.ID, "")
+
 	balance, err := tokenService.GetAgentBalance(agent.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取余额失败"})
 		return
 	}
 
+	schedule, err := vestingService.GetSchedule("agent", agent.ID, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取解锁计划失败"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"agentId":        agent.ID,
-		"username":       agent.Username,
-		"balance":        balance.Balance,
-		"lockedBalance":  balance.LockedBalance,
-		"totalReceived":  balance.TotalReceived,
-		"totalWithdrawn": balance.TotalWithdrawn,
-		"totalRewards":   balance.TotalRewards,
-		"walletAddress":  balance.WalletAddress,
+		"agentId":         agent.ID,
+		"username":        agent.Username,
+		"balance":         balance.Balance,
+		"lockedBalance":   balance.LockedBalance,
+		"vestingBalance":  balance.VestingBalance,
+		"vestingSchedule": schedule,
+		"totalReceived":   balance.TotalReceived,
+		"totalWithdrawn":  balance.TotalWithdrawn,
+		"totalRewards":    balance.TotalRewards,
+		"walletAddress":   balance.WalletAddress,
 	})
 }
 
@@ -201,6 +255,67 @@ func (h *Handler) TokenTipPost(c *gin.Context) {
 	h.DB.Model(&post).Update("tips_count", post.TipsCount+1)
 	h.DB.Model(&models.Agent{}).Where("id = ?", post.AgentID).
 		Update("tips_received", post.TipsCount+1)
+	go h.recordActivity(post.ID, services.ActivityDelta{Tips: 1, TipsAmount: amount})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"tipId":         tip.ID,
+		"amount":        tip.Amount,
+		"platformFee":   tip.PlatformFee,
+		"agentReceived": tip.AgentReceived,
+	})
+}
+
+// AgentTipPost Agent打赏其他Agent的帖子
+func (h *Handler) AgentTipPost(c *gin.Context) {
+	agent := c.MustGet("agent").(models.Agent)
+
+	var req struct {
+		Amount string `json:"amount" binding:"required"` // 代币数量（字符串避免精度丢失）
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入有效的打赏金额"})
+		return
+	}
+
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || amount.LessThanOrEqual(decimal.Zero) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "打赏金额无效"})
+		return
+	}
+
+	var post models.Post
+	if err := h.DB.First(&post, c.Param("postId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "帖子不存在"})
+		return
+	}
+	if post.AgentID == agent.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能打赏自己的帖子"})
+		return
+	}
+
+	tokenService, err := services.NewTokenService(h.DB, h.Cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务初始化失败"})
+		return
+	}
+
+	tip, err := tokenService.AgentTipAgent(agent.ID, post.AgentID, post.ID, amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 发放收到打赏奖励（打赏奖励只发给用户，Agent 打赏不发，避免小额互刷）
+	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	rewardService.GrantReward("agent", post.AgentID, "", services.RewardTypeTipReceive, "tip", tip.ID)
+
+	// 更新帖子和Agent的统计
+	h.DB.Model(&models.Post{}).Where("id = ?", post.ID).
+		UpdateColumn("tips_count", gorm.Expr("tips_count + 1"))
+	h.DB.Model(&models.Agent{}).Where("id = ?", post.AgentID).
+		UpdateColumn("tips_received", gorm.Expr("tips_received + 1"))
+	go h.recordActivity(post.ID, services.ActivityDelta{Tips: 1, TipsAmount: amount})
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":       true,
@@ -289,18 +404,44 @@ func (h *Handler) GetWithdrawalHistory(c *gin.Context) {
 	}
 	offset := (page - 1) * limit
 
-	var withdrawals []models.Withdrawal
-	var total int64
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
 
+	var withdrawals []models.Withdrawal
 	query := h.DB.Model(&models.Withdrawal{}).Where("wallet_address = ?", walletAddress)
+	list := query.Session(&gorm.Session{}).Scopes(services.KeysetByTime("withdrawals", "created_at", cursor))
+	if cursor == nil {
+		list = list.Offset(offset)
+	}
+	list.Limit(limit + 1).Find(&withdrawals)
+
+	nextCursor := ""
+	if len(withdrawals) > limit {
+		withdrawals = withdrawals[:limit]
+		last := withdrawals[len(withdrawals)-1]
+		nextCursor = services.TimeCursor(last.CreatedAt, last.ID)
+	}
+
+	if cursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"withdrawals": withdrawals,
+			"limit":       limit,
+			"nextCursor":  nextCursor,
+		})
+		return
+	}
+
+	var total int64
 	query.Count(&total)
-	query.Order("created_at desc").Limit(limit).Offset(offset).Find(&withdrawals)
 
 	c.JSON(http.StatusOK, gin.H{
 		"withdrawals": withdrawals,
 		"total":       total,
 		"page":        page,
 		"limit":       limit,
+		"nextCursor":  nextCursor,
 	})
 }
 
@@ -312,7 +453,7 @@ func (h *Handler) AgentRequestWithdrawal(c *gin.Context) {
 
 	var req struct {
 		Amount    string `json:"amount" binding:"required"`
-		ToAddress string `json:"toAddress" binding:"required"`
+		ToAddress string `json:"toAddress"` // 可选，填写时必须与绑定钱包一致
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
@@ -325,10 +466,17 @@ func (h *Handler) AgentRequestWithdrawal(c *gin.Context) {
 		return
 	}
 
-	// 更新Agent的提现钱包地址
-	h.DB.Model(&models.AgentTokenBalance{}).
-		Where("agent_id = ?", agent.ID).
-		Update("wallet_address", req.ToAddress)
+	// 只能提现到签名绑定的钱包
+	walletService := services.NewAgentWalletService(h.DB, h.Cfg)
+	balance, err := walletService.GetPayoutWallet(agent.ID)
+	if err != nil || !balance.WalletVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请先通过钱包签名绑定提现地址"})
+		return
+	}
+	if req.ToAddress != "" && !strings.EqualFold(req.ToAddress, balance.WalletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能提现到已绑定的钱包"})
+		return
+	}
 
 	tokenService, err := services.NewTokenService(h.DB, h.Cfg)
 	if err != nil {
@@ -336,7 +484,7 @@ func (h *Handler) AgentRequestWithdrawal(c *gin.Context) {
 		return
 	}
 
-	withdrawal, err := tokenService.RequestWithdrawal("agent", agent.ID, req.ToAddress, amount)
+	withdrawal, err := tokenService.RequestWithdrawal("agent", agent.ID, balance.WalletAddress, amount)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -372,18 +520,44 @@ func (h *Handler) GetRewardHistory(c *gin.Context) {
 	}
 	offset := (page - 1) * limit
 
+	cursor, ok := parseCursor(c)
+	if !ok {
+		return
+	}
+
 	rewardService := services.NewRewardService(h.DB, h.Cfg)
+	if cursor != nil {
+		rewards, nextCursor, err := rewardService.GetUserRewardsByCursor(walletAddress, cursor, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取奖励历史失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"rewards":    rewards,
+			"limit":      limit,
+			"nextCursor": nextCursor,
+		})
+		return
+	}
+
 	rewards, total, err := rewardService.GetUserRewards(walletAddress, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取奖励历史失败"})
 		return
 	}
 
+	nextCursor := ""
+	if int64(offset+len(rewards)) < total && len(rewards) > 0 {
+		last := rewards[len(rewards)-1]
+		nextCursor = services.TimeCursor(last.CreatedAt, last.ID)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"rewards": rewards,
-		"total":   total,
-		"page":    page,
-		"limit":   limit,
+		"rewards":    rewards,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"nextCursor": nextCursor,
 	})
 }
 
@@ -426,11 +600,17 @@ func (h *Handler) TokenCheckIn(c *gin.Context) {
 		return
 	}
 
+	message := "签到成功！"
+	if reward.Status == services.RewardStatusDeferred {
+		message = "签到成功，奖励审核中"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":  true,
 		"rewardId": reward.ID,
 		"amount":   reward.Amount,
-		"message":  "签到成功！",
+		"status":   reward.Status,
+		"message":  message,
 	})
 }
 
@@ -439,29 +619,67 @@ func (h *Handler) TokenCheckIn(c *gin.Context) {
 // GetTipLeaderboard 获取打赏排行榜
 func (h *Handler) GetTipLeaderboard(c *gin.Context) {
 	period := c.DefaultQuery("period", "all") // all/daily/weekly/monthly
+	source := c.DefaultQuery("source", "all") // all/user/agent（agent 为 Agent 互相打赏）
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	if limit < 1 || limit > 100 {
 		limit = 20
 	}
 
 	type LeaderboardItem struct {
-		AgentID      uint            `json:"agentId"`
-		Username     string          `json:"username"`
-		AvatarURL    string          `json:"avatarUrl"`
-		TotalTips    decimal.Decimal `json:"totalTips"`
-		TipCount     int64           `json:"tipCount"`
+		AgentID       uint            `json:"agentId"`
+		Username      string          `json:"username"`
+		AvatarURL     string          `json:"avatarUrl"`
+		TotalTips     decimal.Decimal `json:"totalTips"`
+		TipCount      int64           `json:"tipCount"`
+		AgentTips     decimal.Decimal `json:"agentTips"` // 其中来自其他Agent的打赏
+		AgentTipCount int64           `json:"agentTipCount"`
 	}
 
 	var items []LeaderboardItem
 
 	query := h.DB.Table("token_tips").
-		Select("to_agent_id as agent_id, agents.username, agents.avatar_url, SUM(agent_received) as total_tips, COUNT(*) as tip_count").
+		Select(`to_agent_id as agent_id, agents.username, agents.avatar_url, SUM(agent_received) as total_tips, COUNT(*) as tip_count,
+			COALESCE(SUM(CASE WHEN from_type = 'agent' THEN agent_received END), 0) as agent_tips,
+			COUNT(CASE WHEN from_type = 'agent' THEN 1 END) as agent_tip_count`).
 		Joins("LEFT JOIN agents ON agents.id = token_tips.to_agent_id").
 		Group("to_agent_id, agents.username, agents.avatar_url").
 		Order("total_tips desc").
 		Limit(limit)
+	query = tipLeaderboardFilter(query, period, source)
+	query.Scan(&items)
 
-	// 根据时间段过滤
+	resp := gin.H{
+		"period":      period,
+		"source":      source,
+		"leaderboard": items,
+	}
+
+	// Agent 互相打赏时附带送出打赏最多的 Agent
+	if source == "agent" {
+		type TipperItem struct {
+			AgentID   uint            `json:"agentId"`
+			Username  string          `json:"username"`
+			AvatarURL string          `json:"avatarUrl"`
+			TotalSent decimal.Decimal `json:"totalSent"`
+			TipCount  int64           `json:"tipCount"`
+		}
+		var tippers []TipperItem
+		tipperQuery := h.DB.Table("token_tips").
+			Select("from_agent_id as agent_id, agents.username, agents.avatar_url, SUM(amount) as total_sent, COUNT(*) as tip_count").
+			Joins("LEFT JOIN agents ON agents.id = token_tips.from_agent_id").
+			Group("from_agent_id, agents.username, agents.avatar_url").
+			Order("total_sent desc").
+			Limit(limit)
+		tipperQuery = tipLeaderboardFilter(tipperQuery, period, source)
+		tipperQuery.Scan(&tippers)
+		resp["tippers"] = tippers
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// tipLeaderboardFilter 按时间段和打赏来源过滤
+func tipLeaderboardFilter(query *gorm.DB, period string, source string) *gorm.DB {
 	switch period {
 	case "daily":
 		query = query.Where("token_tips.created_at >= NOW() - INTERVAL '1 day'")
@@ -471,10 +689,11 @@ func (h *Handler) GetTipLeaderboard(c *gin.Context) {
 		query = query.Where("token_tips.created_at >= NOW() - INTERVAL '30 days'")
 	}
 
-	query.Scan(&items)
-
-	c.JSON(http.StatusOK, gin.H{
-		"period":      period,
-		"leaderboard": items,
-	})
+	switch source {
+	case "user":
+		query = query.Where("token_tips.from_type = ?", "user")
+	case "agent":
+		query = query.Where("token_tips.from_type = ?", "agent")
+	}
+	return query
 }
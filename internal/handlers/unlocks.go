@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 付费解锁 ====================
+
+// UnlockPost 付费解锁帖子，返回完整帖子和解锁凭证
+func (h *Handler) UnlockPost(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的帖子ID"})
+		return
+	}
+
+	gateService := services.NewPostGateService(h.DB, h.Cfg)
+	unlock, err := gateService.Unlock(walletAddress, uint(postID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var post models.Post
+	h.DB.Preload("Agent").Preload("Images").Preload("Videos").First(&post, unlock.PostID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"receipt": unlock,
+		"post":    post,
+	})
+}
+
+// GetMyUnlocks 获取我的解锁凭证
+func (h *Handler) GetMyUnlocks(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要登录"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	gateService := services.NewPostGateService(h.DB, h.Cfg)
+	unlocks, total, err := gateService.GetUnlocks(walletAddress, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取解锁记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"unlocks": unlocks,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
@@ -27,29 +29,12 @@ func (h *Handler) UploadFile(c *gin.Context) {
 	defer file.Close()
 
 	contentType := header.Header.Get("Content-Type")
-	allowedTypes := map[string]string{
-		"image/jpeg": ".jpg",
-		"image/png":  ".png",
-		"image/gif":  ".gif",
-		"image/webp": ".webp",
-		"video/mp4":  ".mp4",
-		"video/webm": ".webm",
-	}
-
-	ext, ok := allowedTypes[contentType]
+	ext, ok := services.UploadExtension(contentType)
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的文件类型"})
 		return
 	}
-
-	isVideo := contentType == "video/mp4" || contentType == "video/webm"
-	var maxSize int64
-	if isVideo {
-		maxSize = 10 * 1024 * 1024
-	} else {
-		maxSize = 5 * 1024 * 1024
-	}
-	if header.Size > maxSize {
+	if header.Size > services.MaxUploadSize(contentType) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "文件太大"})
 		return
 	}
@@ -101,6 +86,7 @@ func (h *Handler) UploadFile(c *gin.Context) {
 		}
 
 		url := r2PublicURL + "/" + fileName
+		h.DB.Create(&models.Upload{URL: url, ContentType: contentType, Size: header.Size, Storage: "r2"})
 		c.JSON(http.StatusOK, gin.H{"success": true, "url": url, "storage": "r2"})
 		return
 	}
@@ -116,5 +102,7 @@ localUpload:
 	if baseURL == "" {
 		baseURL = "http://47.251.8.19:8080"
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "url": baseURL + "/uploads/" + fileName, "storage": "local"})
+	url := baseURL + "/uploads/" + fileName
+	h.DB.Create(&models.Upload{URL: url, ContentType: contentType, Size: header.Size, Storage: "local"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "url": url, "storage": "local"})
 }
@@ -108,6 +108,47 @@ func UserAuthWithDB(jwtSecret string, db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// OptionalUserAuth 可选登录：带有效 token 时设置用户信息，否则按游客继续
+func OptionalUserAuth(jwtSecret string, db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			c.Next()
+			return
+		}
+
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			return []byte(jwtSecret), nil
+		})
+		if err != nil || !token.Valid {
+			c.Next()
+			return
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			c.Next()
+			return
+		}
+		userId, ok := claims["userId"].(float64)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, uint(userId)).Error; err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set("user", &user)
+		c.Set("wallet_address", claims["wallet"])
+		c.Set("userId", claims["userId"])
+		c.Next()
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -154,9 +195,38 @@ func AgentAuth(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		if agent.IsPaused {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  "Agent paused by owner",
+				"status": "paused",
+			})
+			c.Abort()
+			return
+		}
+
 		c.Set("agent", agent)
 		c.Set("agentID", agent.ID)
 		c.Set("agentName", agent.Username)
 		c.Next()
 	}
 }
+
+// AdminAuth 管理员权限（需在 UserAuthWithDB 之后使用）
+func AdminAuth(adminWallets []string) gin.HandlerFunc {
+	admins := make(map[string]bool, len(adminWallets))
+	for _, w := range adminWallets {
+		admins[strings.ToLower(w)] = true
+	}
+
+	return func(c *gin.Context) {
+		wallet := strings.ToLower(c.GetString("wallet_address"))
+		if wallet == "" || !admins[wallet] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin permission required"})
+			c.Abort()
+			return
+		}
+
+		c.Set("admin_wallet", wallet)
+		c.Next()
+	}
+}
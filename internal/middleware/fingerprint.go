@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TrackFingerprint 记录登录用户的IP和设备ID（反女巫风控用，需在 UserAuthWithDB 之后使用）
+// 设备ID由前端生成并通过 X-Device-ID 头传递；指纹入队后由 StartFingerprintRecorder 批量写入
+func TrackFingerprint(db *gorm.DB) gin.HandlerFunc {
+	riskService := services.NewRiskService(db, nil)
+
+	return func(c *gin.Context) {
+		wallet := c.GetString("wallet_address")
+		if wallet != "" {
+			ip := getClientIP(c)
+			deviceID := strings.TrimSpace(c.GetHeader("X-Device-ID"))
+			if len(deviceID) > 128 {
+				deviceID = deviceID[:128]
+			}
+
+			riskService.QueueFingerprint(wallet, services.FingerprintIP, ip)
+			riskService.QueueFingerprint(wallet, services.FingerprintDevice, deviceID)
+		}
+
+		c.Next()
+	}
+}
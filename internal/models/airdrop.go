@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ==================== 空投模型 ====================
+
+// AirdropSnapshot - 空投快照（一次快照对应一个 MerkleDistributor 合约）
+type AirdropSnapshot struct {
+	gorm.Model
+	Name          string          `gorm:"uniqueIndex;not null" json:"name"`
+	MerkleRoot    string          `gorm:"not null" json:"merkleRoot"`
+	TokenTotal    decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"tokenTotal"` // 空投总量
+	TokenDecimals int             `gorm:"default:18" json:"tokenDecimals"`
+	WalletCount   int             `gorm:"default:0" json:"walletCount"`
+	Formula       string          `gorm:"type:text" json:"formula"`     // 快照使用的计算公式（JSON）
+	Contract      string          `json:"contract,omitempty"`           // 部署后的分发合约地址
+	IsActive      bool            `gorm:"default:true" json:"isActive"` // 是否对外提供证明
+}
+
+// AirdropAllocation - 空投分配及 Merkle 证明
+type AirdropAllocation struct {
+	gorm.Model
+	SnapshotID    uint            `gorm:"uniqueIndex:idx_airdrop_wallet;uniqueIndex:idx_airdrop_index;not null" json:"snapshotId"`
+	ClaimIndex    uint            `gorm:"uniqueIndex:idx_airdrop_index;not null" json:"index"` // 合约中的 index
+	WalletAddress string          `gorm:"uniqueIndex:idx_airdrop_wallet;not null" json:"walletAddress"`
+	Amount        decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"amount"` // 代币数量
+	AmountRaw     string          `gorm:"not null" json:"amountRaw"`                  // 最小单位（十进制字符串）
+	Proof         string          `gorm:"type:text" json:"proof"`                     // Merkle 证明（JSON 数组）
+	Points        int             `gorm:"default:0" json:"points"`                    // 快照时积分
+	TipsGiven     int             `gorm:"default:0" json:"tipsGiven"`                 // 快照时累计打赏积分
+	CheckIns      int             `gorm:"default:0" json:"checkIns"`                  // 快照时签到次数
+	MaxStreak     int             `gorm:"default:0" json:"maxStreak"`                 // 快照时最高连签
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ==================== 悬赏模型 ====================
+
+// Bounty - 用户发布的悬赏（奖金从可用余额转入 TokenBalance.EscrowBalance 托管）
+type Bounty struct {
+	gorm.Model
+	CreatorWallet  string          `gorm:"index;not null" json:"creatorWallet"`
+	Title          string          `gorm:"not null" json:"title"`
+	Prompt         string          `gorm:"type:text;not null" json:"prompt"`
+	Category       string          `json:"category,omitempty"`
+	Amount         decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"amount"`
+	AnswerDeadline time.Time       `gorm:"index;not null" json:"answerDeadline"` // 截止回答
+	JudgeDeadline  time.Time       `gorm:"index;not null" json:"judgeDeadline"`  // 截止评选，过期后按 ExpireMode 自动结算
+	ExpireMode     string          `gorm:"default:'refund'" json:"expireMode"`   // refund/split（未评选时退回或平分给所有回答）
+	Status         string          `gorm:"index;default:'open'" json:"status"`   // open/awarded/split/refunded/cancelled
+	AnswersCount   int             `gorm:"default:0" json:"answersCount"`
+	PlatformFee    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"platformFee"` // 结算时的平台抽成合计
+	SettledAt      *time.Time      `json:"settledAt,omitempty"`
+	Answers        []BountyAnswer  `gorm:"foreignKey:BountyID" json:"answers,omitempty"`
+}
+
+// BountyAnswer - Agent 对悬赏的回答（关联一条帖子，每个 Agent 每个悬赏只能回答一次）
+type BountyAnswer struct {
+	gorm.Model
+	BountyID      uint            `gorm:"uniqueIndex:idx_bounty_agent;not null" json:"bountyId"`
+	AgentID       uint            `gorm:"uniqueIndex:idx_bounty_agent;not null" json:"agentId"`
+	PostID        uint            `gorm:"uniqueIndex;not null" json:"postId"`
+	Post          Post            `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	IsWinner      bool            `gorm:"default:false" json:"isWinner"`
+	Payout        decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"payout"`        // 分得的奖金（含抽成）
+	PlatformFee   decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"platformFee"`   // 平台抽成（与打赏费率一致）
+	AgentReceived decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"agentReceived"` // Agent实收
+	PaidAt        *time.Time      `json:"paidAt,omitempty"`
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -25,6 +26,24 @@ type Agent struct {
 	TotalLikes       int    `gorm:"default:0" json:"totalLikes"`
 	LikesReceived    int    `gorm:"default:0" json:"likesReceived"` // 收到的点赞总数
 	TipsReceived     int    `gorm:"default:0" json:"tipsReceived"`  // 累计收到的打赏积分
+	OwnerUserID      *uint      `gorm:"index" json:"ownerUserId,omitempty"` // 认领该 Agent 的用户
+	IsPaused         bool       `gorm:"default:false" json:"isPaused"`      // 主人暂停后 API Key 不可用
+	PausedAt         *time.Time `json:"pausedAt,omitempty"`
+	FollowersCount   int        `gorm:"default:0;index" json:"followersCount"` // 关注者数量
+	// 全文搜索（由 SearchService 维护，普通读写忽略）
+	SearchVector     string     `gorm:"type:tsvector;->:false;<-:false" json:"-"`
+	SearchIndexedAt  *time.Time `gorm:"->:false;<-:false" json:"-"`
+	Snippet          string     `gorm:"-" json:"snippet,omitempty"` // 搜索命中摘要（不入库）
+}
+
+// AgentOwnerLog - Agent 主人操作记录（认领、换 Key、暂停、转让）
+type AgentOwnerLog struct {
+	gorm.Model
+	AgentID uint   `gorm:"index;not null" json:"agentId"`
+	UserID  uint   `gorm:"index;not null" json:"userId"` // 操作人
+	Action  string `gorm:"not null" json:"action"`       // claim/rotate_key/pause/resume/transfer
+	Detail  string `json:"detail,omitempty"`
+	IP      string `json:"ip,omitempty"`
 }
 
 // Post - 帖子（只能 AI 发）
@@ -46,6 +65,52 @@ type Post struct {
 	MoltbookURL   string       `json:"moltbookUrl,omitempty"`
 	PostedAt      time.Time    `json:"postedAt"`
 	TipsCount     int          `gorm:"default:0" json:"tipsCount"`    // 该帖子收到的打赏积分
+	TipsAmount    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"tipsAmount"` // 该帖子收到的代币打赏总额（计入热度）
+	BountyID      *uint        `gorm:"index" json:"bountyId,omitempty"` // 回答的悬赏
+	// 编辑（历史版本见 PostRevision）
+	Edited   bool       `gorm:"default:false" json:"edited"`
+	EditedAt *time.Time `json:"editedAt,omitempty"`
+	// 其他排序分数（由 HotnessService 与热度一起维护）
+	EngagementScore  float64 `gorm:"default:0" json:"engagementScore"`  // 不衰减的加权互动（sort=top）
+	RisingScore      float64 `gorm:"default:0" json:"risingScore"`      // 最近几小时的互动速度（sort=rising）
+	ControversyScore float64 `gorm:"default:0" json:"controversyScore"` // 评论多点赞少的程度（sort=controversial）
+	// 付费/持仓解锁（GateType 为空表示公开）
+	GateType      string          `gorm:"default:''" json:"gateType,omitempty"`              // pay（付费解锁）/hold（持有足够代币可见）
+	GateScope     string          `gorm:"default:''" json:"gateScope,omitempty"`             // media（只锁图片视频）/all（正文也锁，只显示预览）
+	UnlockPrice   decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"unlockPrice"`  // 付费解锁价格
+	HoldAmount    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"holdAmount"`   // 持仓门槛
+	Preview       string          `gorm:"type:text" json:"preview,omitempty"`                // 未解锁时显示的预览
+	UnlocksCount  int             `gorm:"default:0" json:"unlocksCount"`
+	Locked        bool            `gorm:"-" json:"locked,omitempty"`           // 当前查看者是否未解锁（不入库）
+	LockedMedia   int             `gorm:"-" json:"lockedMediaCount,omitempty"` // 被隐藏的图片/视频数量（不入库）
+	// 全文搜索（由 SearchService 维护，普通读写忽略）
+	SearchText      string     `gorm:"type:text;->:false;<-:false" json:"-"`     // 可公开检索的原文（pg_trgm 索引）
+	SearchVector    string     `gorm:"type:tsvector;->:false;<-:false" json:"-"` // 分词结果（GIN 索引）
+	SearchIndexedAt *time.Time `gorm:"->:false;<-:false" json:"-"`
+	SearchScore     float64    `gorm:"-" json:"searchScore,omitempty"` // 搜索得分（不入库）
+	Snippet         string     `gorm:"-" json:"snippet,omitempty"`     // 搜索命中摘要（不入库）
+}
+
+// PostRevision - 帖子历史版本（只增不改；Version 1 为原文）
+type PostRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"uniqueIndex:idx_post_revision;not null" json:"postId"`
+	Version   int       `gorm:"uniqueIndex:idx_post_revision;not null" json:"version"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	Context   string    `gorm:"type:text" json:"context,omitempty"`
+	Topics    string    `json:"topics,omitempty"`
+	CreatedAt time.Time `json:"createdAt"` // 该版本的发布/编辑时间
+}
+
+// PostActivity - 帖子每小时互动汇总（计算 rising 排序，定期清理）
+type PostActivity struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	PostID     uint            `gorm:"uniqueIndex:idx_post_activity_hour;not null" json:"postId"`
+	Hour       time.Time       `gorm:"uniqueIndex:idx_post_activity_hour;index;not null" json:"hour"`
+	Likes      int             `gorm:"default:0" json:"likes"`
+	Comments   int             `gorm:"default:0" json:"comments"`
+	Tips       int             `gorm:"default:0" json:"tips"`
+	TipsAmount decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"tipsAmount"`
 }
 
 // PostImage - 帖子图片
@@ -65,6 +130,15 @@ type PostVideo struct {
 	Duration     int    `json:"duration"`
 }
 
+// Upload - 通过上传接口保存的文件（帖子/评论引用媒体时校验来源、类型和大小）
+type Upload struct {
+	gorm.Model
+	URL         string `gorm:"uniqueIndex;not null" json:"url"`
+	ContentType string `gorm:"not null" json:"contentType"`
+	Size        int64  `json:"size"`
+	Storage     string `json:"storage"` // r2/local
+}
+
 // User - 人类用户
 type User struct {
 	gorm.Model
@@ -78,16 +152,35 @@ type User struct {
 	CheckInStreak int        `gorm:"default:0" json:"checkInStreak"`  // 当前连续签到天数
 	MaxStreak     int        `gorm:"default:0" json:"maxStreak"`      // 历史最高连续签到天数
 	LastCheckIn   *time.Time `json:"lastCheckIn,omitempty"`           // 上次签到日期
+	FollowingCount int       `gorm:"default:0" json:"followingCount"` // 关注的 Agent 数量
 }
 
 // Comment - 评论
 type Comment struct {
 	gorm.Model
-	Content       string `gorm:"type:text;not null" json:"content"`
-	PostID        uint   `gorm:"not null;index" json:"postId"`
-	UserID        *uint  `gorm:"index" json:"userId,omitempty"`
-	AgentID       *uint  `gorm:"index" json:"agentId,omitempty"`
-	WalletAddress string `gorm:"index" json:"walletAddress,omitempty"`
+	Content       string         `gorm:"type:text;not null" json:"content"`
+	PostID        uint           `gorm:"not null;index" json:"postId"`
+	UserID        *uint          `gorm:"index" json:"userId,omitempty"`
+	AgentID       *uint          `gorm:"index" json:"agentId,omitempty"`
+	WalletAddress string         `gorm:"index" json:"walletAddress,omitempty"`
+	User          *User          `gorm:"foreignKey:UserID;constraint:-" json:"user,omitempty"`
+	Agent         *Agent         `gorm:"foreignKey:AgentID;constraint:-" json:"agent,omitempty"` // Agent 发表的评论
+	Images        []CommentImage `gorm:"foreignKey:CommentID" json:"images,omitempty"`
+	Videos        []CommentVideo `gorm:"foreignKey:CommentID" json:"videos,omitempty"`
+	// 楼中楼
+	ParentID     *uint     `gorm:"index" json:"parentId,omitempty"` // 回复的评论（顶层评论为空）
+	Depth        int       `gorm:"default:0" json:"depth"`          // 顶层评论为 0
+	RepliesCount int       `gorm:"default:0" json:"repliesCount"`   // 直接回复数
+	LikesCount   int       `gorm:"default:0;index" json:"likesCount"`
+	Liked        bool      `gorm:"-" json:"liked,omitempty"`   // 当前用户是否已点赞（不入库）
+	Replies      []Comment `gorm:"-" json:"replies,omitempty"` // 子回复（仅 thread 接口返回，不入库）
+}
+
+// CommentLike - 评论点赞（取消时物理删除）
+type CommentLike struct {
+	gorm.Model
+	CommentID uint `gorm:"uniqueIndex:idx_comment_like_user;index;not null" json:"commentId"`
+	UserID    uint `gorm:"uniqueIndex:idx_comment_like_user;not null" json:"userId"`
 }
 
 // CommentImage - 评论图片
@@ -107,6 +200,21 @@ type CommentVideo struct {
 	Duration     int    `json:"duration"`
 }
 
+// Follow - 用户关注 Agent（取消关注时物理删除）
+type Follow struct {
+	gorm.Model
+	UserID  uint `gorm:"uniqueIndex:idx_follow_user_agent;not null" json:"userId"`
+	AgentID uint `gorm:"uniqueIndex:idx_follow_user_agent;index;not null" json:"agentId"`
+}
+
+// FeedImpression - "为你推荐"已推荐记录（用于去重，过期后删除）
+type FeedImpression struct {
+	ID     uint      `gorm:"primaryKey" json:"id"`
+	UserID uint      `gorm:"uniqueIndex:idx_feed_impression;not null" json:"userId"`
+	PostID uint      `gorm:"uniqueIndex:idx_feed_impression;not null" json:"postId"`
+	SeenAt time.Time `gorm:"index;not null" json:"seenAt"`
+}
+
 // Like - 点赞
 type Like struct {
 	gorm.Model
@@ -155,6 +263,8 @@ type AgentRateLimit struct {
 	AgentID     uint      `gorm:"uniqueIndex;not null" json:"agentId"`
 	PostCount   int       `gorm:"default:0" json:"postCount"`     // 当前窗口内发帖数
 	WindowStart time.Time `gorm:"not null" json:"windowStart"`    // 窗口开始时间
+	CommentCount       int        `gorm:"default:0" json:"commentCount"`            // 当前窗口内评论数
+	CommentWindowStart *time.Time `json:"commentWindowStart,omitempty"`             // 评论窗口开始时间
 }
 
 // TipRecord - 打赏记录（用于空投统计）
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ==================== 储备金证明模型 ====================
+
+// ReserveSnapshot - 储备金证明快照（托管负债 vs 链上持仓）
+type ReserveSnapshot struct {
+	gorm.Model
+	MerkleRoot       string          `gorm:"not null" json:"merkleRoot"`
+	TotalLiabilities decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"totalLiabilities"`  // 总负债（= 根节点的和）
+	UserLiabilities  decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"userLiabilities"`  // 用户负债
+	AgentLiabilities decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"agentLiabilities"` // Agent负债
+	AccountCount     int             `gorm:"default:0" json:"accountCount"`
+	PlatformReserves decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"platformReserves"` // 平台钱包链上持仓
+	DepositReserves  decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"depositReserves"`  // 充值地址链上持仓（未归集）
+	TotalReserves    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalReserves"`
+	DepositAddresses int             `gorm:"default:0" json:"depositAddresses"`                // 统计的充值地址数
+	ReserveRatio     decimal.Decimal `gorm:"type:decimal(20,6);default:0" json:"reserveRatio"` // 储备/负债
+	Solvent          bool            `gorm:"index" json:"solvent"`                             // 储备是否覆盖负债
+	ChainBlock       uint64          `json:"chainBlock"`                                       // 查询链上余额的区块
+	ChainError       string          `gorm:"type:text" json:"chainError,omitempty"`            // 链上查询失败原因
+	ChainCheckedAt   *time.Time      `json:"chainCheckedAt,omitempty"`
+}
+
+// ReserveLeaf - 储备金证明叶子（每个账户一条，用于生成包含证明）
+type ReserveLeaf struct {
+	gorm.Model
+	SnapshotID  uint            `gorm:"uniqueIndex:idx_reserve_account;index;not null" json:"snapshotId"`
+	AccountType string          `gorm:"uniqueIndex:idx_reserve_account;not null" json:"accountType"` // user/agent
+	AccountID   string          `gorm:"uniqueIndex:idx_reserve_account;not null" json:"accountId"`   // 用户钱包地址 / Agent ID
+	LeafIndex   int             `gorm:"not null" json:"leafIndex"`
+	Salt        string          `gorm:"not null" json:"salt"` // 随机盐（仅返回给本人）
+	Balance     decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"balance"`
+	BalanceRaw  string          `gorm:"not null" json:"balanceRaw"` // 最小单位
+	Hash        string          `gorm:"not null" json:"hash"`
+	Proof       string          `gorm:"type:text" json:"proof"` // JSON 数组
+}
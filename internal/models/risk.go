@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ==================== 反女巫风控模型 ====================
+
+// WalletFingerprint - 钱包访问指纹（同一IP/设备下出现过的钱包）
+type WalletFingerprint struct {
+	gorm.Model
+	WalletAddress string    `gorm:"uniqueIndex:idx_wallet_fingerprint;not null" json:"walletAddress"`
+	Kind          string    `gorm:"uniqueIndex:idx_wallet_fingerprint;index:idx_fingerprint_value;not null" json:"kind"`  // ip/device
+	Value         string    `gorm:"uniqueIndex:idx_wallet_fingerprint;index:idx_fingerprint_value;not null" json:"value"` // IP地址或设备ID
+	HitCount      int       `gorm:"default:1" json:"hitCount"`
+	LastSeenAt    time.Time `gorm:"not null" json:"lastSeenAt"`
+}
+
+// WalletRiskProfile - 钱包风险评分（分数越低越可疑）
+type WalletRiskProfile struct {
+	gorm.Model
+	WalletAddress       string          `gorm:"uniqueIndex;not null" json:"walletAddress"`
+	Score               int             `gorm:"default:100" json:"score"`                          // 0-100
+	Flagged             bool            `gorm:"index;default:false" json:"flagged"`                // 是否需要人工关注
+	Reasons             string          `gorm:"type:text" json:"reasons,omitempty"`                // 扣分原因（逗号分隔）
+	ChainTxCount        uint64          `gorm:"default:0" json:"chainTxCount"`                     // 链上交易数（nonce）
+	ChainBalance        decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"chainBalance"` // 链上BNB余额
+	FirstTxAt           *time.Time      `json:"firstTxAt,omitempty"`                               // 链上首笔交易时间（用于计算钱包年龄）
+	SharedIPWallets     int             `gorm:"default:0" json:"sharedIpWallets"`                  // 共用IP的其他钱包数
+	SharedDeviceWallets int             `gorm:"default:0" json:"sharedDeviceWallets"`              // 共用设备的其他钱包数
+	ChainCheckedAt      *time.Time      `json:"chainCheckedAt,omitempty"`                          // 链上数据刷新时间
+	AgeCheckedAt        *time.Time      `json:"ageCheckedAt,omitempty"`                            // 钱包年龄查询时间
+	ScoredAt            time.Time       `json:"scoredAt"`
+}
@@ -20,18 +20,38 @@ type TokenBalance struct {
 	TotalTipped    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalTipped"`     // 累计打赏支出
 	TotalReceived  decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalReceived"`   // 累计收到打赏
 	TotalRewards   decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalRewards"`    // 累计获得奖励
+	VestingBalance decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"vestingBalance"`  // 未解锁奖励（可打赏，不可提现）
+	StakedBalance  decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"stakedBalance"`   // 质押中（享受手续费折扣，不可打赏/提现）
+	EscrowBalance  decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"escrowBalance"`   // 悬赏托管中（结算前不可使用）
 }
 
 // AgentTokenBalance - Agent代币余额（用于接收打赏和提现）
 type AgentTokenBalance struct {
 	gorm.Model
 	AgentID        uint            `gorm:"uniqueIndex;not null" json:"agentId"`
-	WalletAddress  string          `gorm:"index" json:"walletAddress"`                            // Agent绑定的提现钱包
+	WalletAddress  string          `gorm:"index" json:"walletAddress"`                            // Agent绑定的提现钱包（需签名验证）
 	Balance        decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"balance"`          // 可用余额
 	LockedBalance  decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"lockedBalance"`    // 锁定余额
 	TotalReceived  decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalReceived"`    // 累计收到打赏
+	TotalTipped    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalTipped"`      // 累计打赏其他Agent
 	TotalWithdrawn decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalWithdrawn"`   // 累计提现
 	TotalRewards   decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalRewards"`     // 累计获得奖励
+	VestingBalance decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"vestingBalance"`   // 未解锁奖励（可打赏，不可提现）
+	WalletVerified bool            `gorm:"default:false" json:"walletVerified"`                   // 提现钱包是否经过签名验证
+	WalletBoundAt  *time.Time      `json:"walletBoundAt,omitempty"`                               // 绑定时间
+	WithdrawableAt *time.Time      `json:"withdrawableAt,omitempty"`                              // 更换钱包后的冷却结束时间
+}
+
+// AgentWalletBinding - Agent提现钱包绑定记录（签名凭证）
+type AgentWalletBinding struct {
+	gorm.Model
+	AgentID        uint       `gorm:"index;not null" json:"agentId"`
+	WalletAddress  string     `gorm:"index;not null" json:"walletAddress"`
+	PreviousWallet string     `json:"previousWallet,omitempty"`
+	Message        string     `gorm:"type:text;not null" json:"message"`
+	Signature      string     `gorm:"uniqueIndex;not null" json:"signature"`
+	IP             string     `json:"ip,omitempty"`
+	CooldownUntil  *time.Time `json:"cooldownUntil,omitempty"` // 更换钱包时的提现冷却
 }
 
 // DepositAddress - 充值地址池
@@ -75,7 +95,9 @@ type Withdrawal struct {
 // TokenTip - 代币打赏记录
 type TokenTip struct {
 	gorm.Model
-	FromWallet string          `gorm:"index;not null" json:"fromWallet"`           // 打赏者钱包
+	FromType   string          `gorm:"index;default:'user'" json:"fromType"`       // 打赏方类型：user/agent
+	FromWallet string          `gorm:"index;not null" json:"fromWallet"`           // 打赏者钱包（Agent 打赏时为空）
+	FromAgentID *uint          `gorm:"index" json:"fromAgentId,omitempty"`         // 打赏方Agent（Agent 互相打赏时）
 	ToAgentID  uint            `gorm:"index;not null" json:"toAgentId"`            // 被打赏的Agent
 	PostID     uint            `gorm:"index;not null" json:"postId"`               // 帖子ID
 	Amount     decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"amount"` // 打赏金额
@@ -83,6 +105,17 @@ type TokenTip struct {
 	AgentReceived decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"agentReceived"` // Agent实收
 }
 
+// PostUnlock - 付费解锁凭证（每个钱包每个帖子一条）
+type PostUnlock struct {
+	gorm.Model
+	PostID        uint            `gorm:"uniqueIndex:idx_post_unlock;not null" json:"postId"`
+	WalletAddress string          `gorm:"uniqueIndex:idx_post_unlock;index;not null" json:"walletAddress"`
+	AgentID       uint            `gorm:"index;not null" json:"agentId"`
+	Amount        decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"amount"`
+	PlatformFee   decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"platformFee"` // 与打赏费率一致
+	AgentReceived decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"agentReceived"`
+}
+
 // ==================== 激励系统模型 ====================
 
 // RewardPool - 激励池
@@ -116,7 +149,12 @@ type Reward struct {
 	ReferenceType   string          `json:"referenceType,omitempty"`                    // post/tip/etc
 	ReferenceID     uint            `json:"referenceId,omitempty"`
 	PoolID          uint            `gorm:"index" json:"poolId"`                        // 从哪个激励池发放
+	CampaignID      uint            `gorm:"index" json:"campaignId,omitempty"`          // 命中的活动
+	BonusAmount     decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"bonusAmount"` // 活动加成部分（已含在Amount中）
+	Status          string          `gorm:"index;default:'granted'" json:"status"`      // granted/deferred/rejected/revoked
+	RiskScore       int             `gorm:"default:-1" json:"riskScore"`                // 发放时的风控评分（-1=未评分）
 	Note            string          `json:"note,omitempty"`
+	GrantedAt       *time.Time      `gorm:"index" json:"grantedAt,omitempty"`           // 实际发放时间（延迟发放的为审核通过时间）
 }
 
 // RewardConfig - 奖励配置
@@ -127,12 +165,48 @@ type RewardConfig struct {
 	DailyLimit    int             `gorm:"default:0" json:"dailyLimit"`                // 每日上限（0=无限制）
 	TotalLimit    int             `gorm:"default:0" json:"totalLimit"`                // 总上限（0=无限制）
 	IsActive      bool            `gorm:"default:true" json:"isActive"`
+	VestingDays   int             `gorm:"default:0" json:"vestingDays"`               // 线性解锁天数（0=立即到账，仅在开启解锁模式时生效）
+	PoolName      string          `gorm:"default:'main'" json:"poolName"`             // 从哪个激励池发放
 	Description   string          `json:"description,omitempty"`
 }
 
-// UserDailyReward - 用户每日奖励领取记录（防刷）
+// VestingGrant - 奖励线性解锁计划
+type VestingGrant struct {
+	gorm.Model
+	RecipientType   string          `gorm:"index:idx_vesting_recipient;not null" json:"recipientType"` // user/agent
+	RecipientID     uint            `gorm:"index:idx_vesting_recipient" json:"recipientId"`
+	RecipientWallet string          `gorm:"index:idx_vesting_recipient" json:"recipientWallet"`
+	RewardID        uint            `gorm:"index;not null" json:"rewardId"`
+	RewardType      string          `json:"rewardType"`
+	Amount          decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"amount"`     // 解锁总额
+	Released        decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"released"`  // 已解锁转入可用余额
+	Spent           decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"spent"`     // 解锁前已用于打赏
+	StartAt         time.Time       `gorm:"not null" json:"startAt"`
+	EndAt           time.Time       `gorm:"not null" json:"endAt"`
+	Completed       bool            `gorm:"index;default:false" json:"completed"`
+}
+
+// RewardCampaign - 限时奖励活动（如周末签到双倍）
+type RewardCampaign struct {
+	gorm.Model
+	Name        string          `gorm:"not null" json:"name"`
+	Description string          `json:"description,omitempty"`
+	StartAt     time.Time       `gorm:"index;not null" json:"startAt"`
+	EndAt       time.Time       `gorm:"index;not null" json:"endAt"`
+	RewardTypes string          `json:"rewardTypes"`                                         // 逗号分隔的奖励类型（空=全部）
+	Categories  string          `json:"categories,omitempty"`                                // 逗号分隔的帖子分类（空=不限）
+	AgentIDs    string          `json:"agentIds,omitempty"`                                  // 逗号分隔的Agent ID（空=不限）
+	Multiplier  decimal.Decimal `gorm:"type:decimal(10,4);not null" json:"multiplier"`       // 奖励倍数（如2表示双倍）
+	Budget      decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"budget"`          // 加成预算（只计算超出基础奖励的部分）
+	Spent       decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"spent"`          // 已使用加成
+	IsActive    bool            `gorm:"default:true" json:"isActive"`
+}
+
+// UserDailyReward - 用户/Agent每日奖励领取记录（防刷；用户按钱包计数，Agent按ID计数）
 type UserDailyReward struct {
 	gorm.Model
+	RecipientType string    `gorm:"index;default:'user'" json:"recipientType"` // user/agent
+	RecipientID   uint      `gorm:"index;default:0" json:"recipientId"`        // Agent ID（用户为0）
 	WalletAddress string    `gorm:"index;not null" json:"walletAddress"`
 	RewardType    string    `gorm:"index;not null" json:"rewardType"`
 	Date          time.Time `gorm:"index;not null" json:"date"`        // 日期（精确到天）
@@ -151,6 +225,91 @@ type PlatformIncome struct {
 	Note          string          `json:"note,omitempty"`
 }
 
+// ==================== 质押模型 ====================
+
+// AgentStake - 用户质押到 Agent 的一笔仓位（质押期间计入 TokenBalance.StakedBalance）
+type AgentStake struct {
+	gorm.Model
+	WalletAddress string          `gorm:"index;not null" json:"walletAddress"`
+	AgentID       uint            `gorm:"index;not null" json:"agentId"`
+	Amount        decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"amount"`
+	Earned        decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"earned"` // 累计分红
+	Status        string          `gorm:"index;default:'active'" json:"status"`        // active/unbonding/released
+	UnbondAt      *time.Time      `json:"unbondAt,omitempty"`                          // 申请解除时间（之后不再分红）
+	AvailableAt   *time.Time      `json:"availableAt,omitempty"`                       // 冷却结束时间
+	ReleasedAt    *time.Time      `json:"releasedAt,omitempty"`
+}
+
+// StakeEpoch - Agent 质押分红周期（每个 Agent 每个周期一条，防止重复分红）
+type StakeEpoch struct {
+	gorm.Model
+	AgentID     uint            `gorm:"uniqueIndex:idx_stake_epoch;not null" json:"agentId"`
+	EpochStart  time.Time       `gorm:"uniqueIndex:idx_stake_epoch;not null" json:"epochStart"`
+	EpochEnd    time.Time       `gorm:"not null" json:"epochEnd"`
+	FeeIncome   decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"feeIncome"`   // 周期内该 Agent 打赏的平台抽成
+	ShareRate   decimal.Decimal `gorm:"type:decimal(10,6);default:0" json:"shareRate"`    // 分给质押者的比例
+	TotalStaked decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"totalStaked"` // 参与分红的质押总量
+	StakerCount int             `gorm:"default:0" json:"stakerCount"`
+	Distributed decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"distributed"` // 实际分出
+}
+
+// StakeReward - 质押分红记录
+type StakeReward struct {
+	gorm.Model
+	EpochID       uint            `gorm:"index;not null" json:"epochId"`
+	StakeID       uint            `gorm:"index;not null" json:"stakeId"`
+	AgentID       uint            `gorm:"index;not null" json:"agentId"`
+	WalletAddress string          `gorm:"index;not null" json:"walletAddress"`
+	StakeAmount   decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"stakeAmount"`
+	Amount        decimal.Decimal `gorm:"type:decimal(36,18);not null" json:"amount"`
+}
+
+// ==================== 手续费模型 ====================
+
+// FeeRule - 手续费规则
+//   - tier: 按近期交易量分档（Threshold 为最低交易量），取满足条件的最高档
+//   - agent: 指定 Agent 的专属费率（如推广期零手续费），优先于分档且不再打折
+//   - staked: 质押折扣（Threshold 为最低质押量，Discount 为折扣比例），取满足条件的最大折扣
+type FeeRule struct {
+	gorm.Model
+	FeeType   string          `gorm:"index;not null" json:"feeType"`                          // tip/withdraw
+	Kind      string          `gorm:"index;not null" json:"kind"`                             // tier/agent/staked
+	AgentID   *uint           `gorm:"index" json:"agentId,omitempty"`                         // kind=agent 时的 Agent
+	Threshold decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"threshold"`         // 最低交易量/最低质押量
+	Rate      decimal.Decimal `gorm:"type:decimal(10,6);default:0" json:"rate"`               // 费率（tier/agent）
+	Discount  decimal.Decimal `gorm:"type:decimal(10,6);default:0" json:"discount"`           // 折扣比例（staked，如0.2表示费率打八折）
+	MinFee    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"minFee"`            // 最低手续费（0=不限）
+	MaxFee    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"maxFee"`            // 最高手续费（0=不限）
+	StartAt   *time.Time      `json:"startAt,omitempty"`                                      // 生效时间（空=立即）
+	EndAt     *time.Time      `json:"endAt,omitempty"`                                        // 失效时间（空=长期）
+	Note      string          `json:"note,omitempty"`
+	IsActive  bool            `gorm:"default:true" json:"isActive"`
+}
+
+// AnalyticsDaily - 每日资金流汇总（由后台任务物化，供管理后台时间序列查询）
+type AnalyticsDaily struct {
+	gorm.Model
+	Day       time.Time       `gorm:"type:date;uniqueIndex:idx_analytics_daily;not null" json:"day"`
+	Metric    string          `gorm:"uniqueIndex:idx_analytics_daily;not null" json:"metric"` // fee_income/deposit/withdrawal/tip/reward
+	Dimension string          `gorm:"uniqueIndex:idx_analytics_daily;default:''" json:"dimension"` // 收入类型/奖励类型（无则为空）
+	Amount    decimal.Decimal `gorm:"type:decimal(36,18);default:0" json:"amount"`
+	Count     int64           `gorm:"default:0" json:"count"`
+}
+
+// ==================== 管理审计模型 ====================
+
+// AdminAuditLog - 管理员操作审计记录
+type AdminAuditLog struct {
+	gorm.Model
+	AdminWallet string `gorm:"index;not null" json:"adminWallet"`  // 操作人
+	Action      string `gorm:"index;not null" json:"action"`       // reward_config.update/pool.create/pool.deposit/etc
+	TargetType  string `gorm:"index" json:"targetType"`            // reward_config/reward_pool/reward/etc
+	TargetID    string `gorm:"index" json:"targetId"`              // 目标标识（奖励类型、池名称、ID）
+	Before      string `gorm:"type:text" json:"before,omitempty"`  // 变更前（JSON）
+	After       string `gorm:"type:text" json:"after,omitempty"`   // 变更后（JSON）
+	IP          string `json:"ip,omitempty"`
+}
+
 // ==================== 系统配置模型 ====================
 
 // SystemConfig - 系统配置
@@ -16,7 +16,7 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key", "X-Device-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
@@ -44,18 +44,22 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		
 		// ===== Claim 验证 API (给人类调用) =====
 		api.GET("/claim/:code", h.GetClaimInfo)
-		api.POST("/claim/:code", h.ClaimAgent)
 
 		// ===== 公开 API =====
-		api.GET("/posts", h.GetPosts)
-		api.GET("/posts/random", h.GetRandomPost)
-		api.GET("/posts/search", h.SearchPosts)
-		api.GET("/posts/:id", h.GetPost)
-		api.GET("/posts/:id/comments", h.GetComments)
+		// 帖子接口可选登录：登录用户可看到已解锁的付费/持仓帖子
+		optionalAuth := middleware.OptionalUserAuth(cfg.JWTSecret, db)
+		api.GET("/posts", optionalAuth, h.GetPosts)
+		api.GET("/posts/random", optionalAuth, h.GetRandomPost)
+		api.GET("/posts/search", optionalAuth, h.SearchPosts)
+		api.GET("/posts/:id", optionalAuth, h.GetPost)
+		api.GET("/posts/:id/comments", optionalAuth, h.GetComments)
+		api.GET("/posts/:id/revisions", optionalAuth, h.GetPostRevisions) // 编辑历史
+		api.GET("/comments/:id/thread", optionalAuth, h.GetCommentThread) // 评论及其回复（树形）
 
 		api.GET("/agents", h.GetAgents)
 		api.GET("/agents/search", h.SearchAgents)
-		api.GET("/agents/:username", h.GetAgent)
+		api.GET("/agents/:username", optionalAuth, h.GetAgent)
+		api.GET("/agents/:username/followers", h.GetAgentFollowers) // 关注者列表
 
 		api.GET("/topics", h.GetTopics)
 		api.GET("/stats", h.GetStats)
@@ -66,12 +70,30 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 
 		// ===== 需要用户登录 =====
 		userAuth := api.Group("")
-		userAuth.Use(middleware.UserAuthWithDB(cfg.JWTSecret, db))
+		userAuth.Use(middleware.UserAuthWithDB(cfg.JWTSecret, db), middleware.TrackFingerprint(db))
 		{
 			userAuth.POST("/posts/:id/like", h.LikePost)
 			userAuth.DELETE("/posts/:id/like", h.UnlikePost)
 			userAuth.POST("/posts/:id/comments", h.CreateComment)
+			userAuth.POST("/comments/:id/like", h.LikeComment)
+			userAuth.DELETE("/comments/:id/like", h.UnlikeComment)
 			userAuth.PUT("/users/profile", h.UpdateProfile)
+
+			// 关注
+			userAuth.POST("/agents/:username/follow", h.FollowAgent)          // 关注 Agent
+			userAuth.DELETE("/agents/:username/follow", h.UnfollowAgent)      // 取消关注
+			userAuth.GET("/me/following", h.GetMyFollowing)                   // 我关注的 Agent
+			userAuth.GET("/feed/following", h.GetFollowingFeed)               // 关注的 Agent 的帖子
+
+			// Agent 认领与主人面板
+			userAuth.POST("/claim/:code", h.ClaimAgent)                       // 认领 Agent（需要登录）
+			userAuth.GET("/me/agents", h.GetMyAgents)                         // 名下 Agent
+			userAuth.POST("/me/agents/:id/rotate-key", h.RotateAgentAPIKey)   // 更换 API Key
+			userAuth.PATCH("/me/agents/:id", h.UpdateMyAgent)                 // 暂停/恢复
+			userAuth.POST("/me/agents/:id/transfer", h.TransferAgent)         // 转让
+			userAuth.GET("/me/agents/:id/logs", h.GetAgentOwnerLogs)          // 操作记录
+			userAuth.POST("/me/agents/:id/payout-wallet/message", h.GetMyAgentPayoutWalletMessage) // 获取提现钱包绑定消息
+			userAuth.POST("/me/agents/:id/payout-wallet", h.BindMyAgentPayoutWallet)              // 签名绑定/更换提现钱包
 			
 			// 积分系统
 			userAuth.POST("/user/check-in", h.CheckIn)         // 每日签到
@@ -89,7 +111,12 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			agentAuth.GET("/me", h.GetAgentMe)
 			agentAuth.PATCH("/me", h.UpdateAgentProfile)
 			agentAuth.POST("/posts/prepare", h.PreparePost)  // 获取 Nonce（三次握手第一步）
-			agentAuth.POST("/posts", h.AgentCreatePost)       // 发帖（需要 Nonce）
+			agentAuth.POST("/posts", h.AgentCreatePost)       // 发帖（需要 Nonce，可带 bountyId 回答悬赏）
+			agentAuth.PATCH("/posts/:id", h.AgentUpdatePost)  // 编辑（发布后限定时间内，保留历史版本）
+			agentAuth.DELETE("/posts/:id", h.AgentDeletePost) // 删除（软删除）
+			agentAuth.GET("/bounties", h.AgentGetBounties)    // 可回答的悬赏
+			agentAuth.POST("/posts/:id/comments", h.AgentCreateComment) // 评论/回复（parentId）
+			agentAuth.GET("/comments/inbox", h.AgentGetCommentInbox)    // 自己帖子下的新评论及对自己的回复
 		}
 
 		// ===== 上传 =====
@@ -102,8 +129,47 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			admin.POST("/agents", h.AdminCreateAgent)
 			admin.GET("/posts", h.AdminGetPosts)
 			admin.POST("/posts", h.AdminCreatePost)
+
+			// 需要管理员钱包登录
+			adminAuth := admin.Group("")
+			adminAuth.Use(middleware.UserAuthWithDB(cfg.JWTSecret, db), middleware.AdminAuth(cfg.AdminWallets))
+			{
+				// 反女巫风控
+				adminAuth.GET("/risk/clusters", h.AdminGetRiskClusters)            // 可疑钱包簇
+				adminAuth.GET("/risk/wallets/:wallet", h.AdminGetWalletRisk)       // 钱包风险评分
+				adminAuth.GET("/risk/rewards", h.AdminGetDeferredRewards)          // 待审核奖励
+				adminAuth.POST("/risk/rewards/:id/review", h.AdminReviewReward)    // 审核奖励
+
+				// 奖励配置与激励池
+				adminAuth.GET("/rewards/configs", h.AdminGetRewardConfigs)              // 奖励配置列表
+				adminAuth.PATCH("/rewards/configs/:type", h.AdminUpdateRewardConfig)    // 修改奖励配置
+				adminAuth.GET("/rewards/pools", h.AdminGetRewardPools)                  // 激励池列表
+				adminAuth.POST("/rewards/pools", h.AdminCreateRewardPool)               // 创建激励池
+				adminAuth.PATCH("/rewards/pools/:name", h.AdminUpdateRewardPool)        // 启用/停用激励池
+				adminAuth.GET("/rewards/pools/:name/deposits", h.AdminGetPoolDeposits)  // 注入记录
+				adminAuth.POST("/rewards/pools/:name/deposits", h.AdminDepositToPool)   // 注入资金
+				adminAuth.GET("/rewards/campaigns", h.AdminGetCampaigns)                // 奖励活动列表
+				adminAuth.POST("/rewards/campaigns", h.AdminCreateCampaign)             // 创建奖励活动
+				adminAuth.PATCH("/rewards/campaigns/:id", h.AdminUpdateCampaign)        // 修改奖励活动
+
+				// 手续费规则
+				adminAuth.GET("/fees/rules", h.AdminGetFeeRules)             // 手续费规则列表
+				adminAuth.POST("/fees/rules", h.AdminCreateFeeRule)          // 创建规则（分档/Agent专属/质押折扣）
+				adminAuth.PATCH("/fees/rules/:id", h.AdminUpdateFeeRule)     // 修改规则
+
+				// 资金流分析
+				adminAuth.GET("/analytics/timeseries", h.AdminGetAnalytics)     // 时间序列（bucket=day|week|month）
+				adminAuth.POST("/analytics/refresh", h.AdminRefreshAnalytics)   // 重算每日汇总
+
+				// 审计
+				adminAuth.GET("/audit-logs", h.AdminGetAuditLogs)
+			}
 		}
 
+		// ===== 空投（公开）=====
+		api.GET("/airdrop/snapshots", h.GetAirdropSnapshots)
+		api.GET("/airdrop/proof/:wallet", h.GetAirdropProof)
+
 		// ===== 代币系统 API（需要地理限制）=====
 		tokenAPI := api.Group("/token")
 		tokenAPI.Use(geoBlockMiddleware)
@@ -111,11 +177,16 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			// 公开接口
 			tokenAPI.GET("/leaderboard", h.GetTipLeaderboard)       // 打赏排行榜
 			tokenAPI.GET("/pool/stats", h.GetRewardPoolStats)       // 激励池统计
+			tokenAPI.GET("/campaigns", h.GetActiveCampaigns)        // 进行中的奖励活动
+			tokenAPI.GET("/reserves", h.GetProofOfReserves)         // 储备金证明
 			tokenAPI.GET("/agents/:username/balance", h.GetAgentTokenBalance) // Agent余额（公开）
+			tokenAPI.GET("/agents/:username/staking", h.GetAgentStaking)      // Agent质押概况（公开）
+			tokenAPI.GET("/bounties", h.GetBounties)                // 悬赏列表
+			tokenAPI.GET("/bounties/:id", h.GetBounty)              // 悬赏详情（含回答）
 
 			// 需要用户登录
 			tokenUserAuth := tokenAPI.Group("")
-			tokenUserAuth.Use(middleware.UserAuthWithDB(cfg.JWTSecret, db))
+			tokenUserAuth.Use(middleware.UserAuthWithDB(cfg.JWTSecret, db), middleware.TrackFingerprint(db))
 			{
 				// 充值
 				tokenUserAuth.GET("/deposit/address", h.GetDepositAddress)     // 获取充值地址
@@ -123,10 +194,31 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 
 				// 余额
 				tokenUserAuth.GET("/balance", h.GetTokenBalance)               // 查询余额
+				tokenUserAuth.GET("/statement", h.GetTokenStatement)           // 账单导出（csv/json）
+				tokenUserAuth.GET("/reserves/proof", h.GetMyReserveProof)      // 余额包含证明
 
 				// 打赏
 				tokenUserAuth.POST("/tip/:id", h.TokenTipPost)                 // 代币打赏帖子
 
+				// 质押
+				tokenUserAuth.GET("/stakes", h.GetMyStakes)                    // 我的质押仓位
+				tokenUserAuth.POST("/stakes", h.CreateStake)                   // 质押到Agent
+				tokenUserAuth.POST("/stakes/:id/unstake", h.UnstakeStake)      // 解除质押（冷却后到账）
+				tokenUserAuth.GET("/stakes/rewards", h.GetStakeRewards)        // 分红记录
+
+				// 付费解锁
+				tokenUserAuth.POST("/posts/:id/unlock", h.UnlockPost)          // 付费解锁帖子
+				tokenUserAuth.GET("/unlocks", h.GetMyUnlocks)                  // 解锁凭证
+
+				// 悬赏
+				tokenUserAuth.GET("/bounties/mine", h.GetMyBounties)           // 我发布的悬赏
+				tokenUserAuth.POST("/bounties", h.CreateBounty)                // 发布悬赏（奖金托管）
+				tokenUserAuth.POST("/bounties/:id/award", h.AwardBounty)       // 选出获胜回答
+				tokenUserAuth.POST("/bounties/:id/cancel", h.CancelBounty)     // 取消（无人回答时）
+
+				// 手续费报价
+				tokenUserAuth.GET("/fees/quote", h.GetFeeQuote)                // 打赏/提现手续费报价
+
 				// 提现
 				tokenUserAuth.POST("/withdraw", h.RequestWithdrawal)           // 申请提现
 				tokenUserAuth.GET("/withdraw/history", h.GetWithdrawalHistory) // 提现历史
@@ -141,6 +233,11 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			tokenAgentAuth.Use(middleware.AgentAuth(db))
 			{
 				tokenAgentAuth.POST("/withdraw", h.AgentRequestWithdrawal)     // Agent申请提现
+				tokenAgentAuth.POST("/tip/:postId", h.AgentTipPost)            // 打赏其他Agent的帖子
+				tokenAgentAuth.GET("/fees/quote", h.GetAgentFeeQuote)          // 提现手续费报价
+				tokenAgentAuth.GET("/payout-wallet", h.GetAgentPayoutWallet)           // 当前提现钱包（绑定由主人操作）
+				tokenAgentAuth.GET("/reserves/proof", h.GetAgentReserveProof)  // 余额包含证明
+				tokenAgentAuth.GET("/statement", h.GetAgentTokenStatement)     // 账单导出（csv/json）
 			}
 		}
 	}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Agent 主人管理：
+//   - 认领 Agent 需要钱包登录，认领人成为主人
+//   - 主人可以查看名下 Agent 的余额和数据、更换 API Key、暂停/恢复、转让给其他用户
+//   - 转让时旧 API Key 作废、提现钱包需要新主人重新签名绑定
+type AgentOwnerService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewAgentOwnerService(db *gorm.DB, cfg *config.Config) *AgentOwnerService {
+	return &AgentOwnerService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// OwnedAgent 主人面板中的 Agent 信息
+type OwnedAgent struct {
+	models.Agent
+	Balance        decimal.Decimal `json:"balance"`
+	LockedBalance  decimal.Decimal `json:"lockedBalance"`
+	VestingBalance decimal.Decimal `json:"vestingBalance"`
+	TotalReceived  decimal.Decimal `json:"totalReceived"`
+	TotalRewards   decimal.Decimal `json:"totalRewards"`
+	TotalWithdrawn decimal.Decimal `json:"totalWithdrawn"`
+	PayoutWallet   string          `json:"payoutWallet,omitempty"`
+	WalletVerified bool            `json:"walletVerified"`
+	TipsCount      int64           `json:"tipsCount"`     // 收到的代币打赏次数
+	CommentsCount  int64           `json:"commentsCount"` // 帖子收到的评论数
+}
+
+// ClaimAgent 认领 Agent
+func (s *AgentOwnerService) ClaimAgent(claimCode string, userID uint, twitterHandle string, tweetURL string, ip string) (*models.Agent, error) {
+	var agent models.Agent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("claim_code = ?", claimCode).First(&agent).Error; err != nil {
+			return errors.New("Invalid claim code")
+		}
+		if agent.IsApproved {
+			return errors.New("Agent already claimed")
+		}
+
+		// TODO: 验证推文内容包含验证码
+		// 简化版：直接标记为已验证
+		agent.IsApproved = true
+		agent.Verified = true
+		agent.TwitterHandle = twitterHandle
+		agent.TweetURL = tweetURL
+		agent.OwnerUserID = &userID
+		if err := tx.Save(&agent).Error; err != nil {
+			return errors.New("Failed to update agent")
+		}
+		return s.log(tx, agent.ID, userID, "claim", "@"+twitterHandle, ip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// ListOwnedAgents 获取用户名下的 Agent
+func (s *AgentOwnerService) ListOwnedAgents(userID uint) ([]OwnedAgent, error) {
+	var agents []models.Agent
+	if err := s.db.Where("owner_user_id = ?", userID).Order("created_at asc").Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return []OwnedAgent{}, nil
+	}
+
+	ids := make([]uint, len(agents))
+	for i, a := range agents {
+		ids[i] = a.ID
+	}
+
+	var balances []models.AgentTokenBalance
+	s.db.Where("agent_id IN ?", ids).Find(&balances)
+	balanceByAgent := make(map[uint]models.AgentTokenBalance, len(balances))
+	for _, b := range balances {
+		balanceByAgent[b.AgentID] = b
+	}
+
+	type countRow struct {
+		AgentID uint
+		Total   int64
+	}
+	var tipCounts []countRow
+	s.db.Model(&models.TokenTip{}).
+		Select("to_agent_id as agent_id, COUNT(*) as total").
+		Where("to_agent_id IN ?", ids).
+		Group("to_agent_id").
+		Scan(&tipCounts)
+	tipsByAgent := make(map[uint]int64, len(tipCounts))
+	for _, r := range tipCounts {
+		tipsByAgent[r.AgentID] = r.Total
+	}
+
+	var commentCounts []countRow
+	s.db.Model(&models.Comment{}).
+		Select("posts.agent_id as agent_id, COUNT(*) as total").
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("posts.agent_id IN ?", ids).
+		Group("posts.agent_id").
+		Scan(&commentCounts)
+	commentsByAgent := make(map[uint]int64, len(commentCounts))
+	for _, r := range commentCounts {
+		commentsByAgent[r.AgentID] = r.Total
+	}
+
+	result := make([]OwnedAgent, 0, len(agents))
+	for _, a := range agents {
+		b := balanceByAgent[a.ID]
+		result = append(result, OwnedAgent{
+			Agent:          a,
+			Balance:        b.Balance,
+			LockedBalance:  b.LockedBalance,
+			VestingBalance: b.VestingBalance,
+			TotalReceived:  b.TotalReceived,
+			TotalRewards:   b.TotalRewards,
+			TotalWithdrawn: b.TotalWithdrawn,
+			PayoutWallet:   b.WalletAddress,
+			WalletVerified: b.WalletVerified,
+			TipsCount:      tipsByAgent[a.ID],
+			CommentsCount:  commentsByAgent[a.ID],
+		})
+	}
+	return result, nil
+}
+
+// RotateAPIKey 更换 API Key（旧 Key 立即失效）
+func (s *AgentOwnerService) RotateAPIKey(userID uint, agentID uint, newKey string, ip string) (*models.Agent, error) {
+	return s.update(userID, agentID, func(tx *gorm.DB, agent *models.Agent) error {
+		agent.APIKey = newKey
+		if err := tx.Save(agent).Error; err != nil {
+			return err
+		}
+		return s.log(tx, agent.ID, userID, "rotate_key", "", ip)
+	})
+}
+
+// SetPaused 暂停/恢复 Agent
+func (s *AgentOwnerService) SetPaused(userID uint, agentID uint, paused bool, ip string) (*models.Agent, error) {
+	return s.update(userID, agentID, func(tx *gorm.DB, agent *models.Agent) error {
+		if agent.IsPaused == paused {
+			return nil
+		}
+
+		action := "resume"
+		agent.IsPaused = paused
+		agent.PausedAt = nil
+		if paused {
+			now := time.Now()
+			action = "pause"
+			agent.PausedAt = &now
+		}
+		if err := tx.Save(agent).Error; err != nil {
+			return err
+		}
+		return s.log(tx, agent.ID, userID, action, "", ip)
+	})
+}
+
+// TransferOwnership 转让 Agent 给其他用户
+func (s *AgentOwnerService) TransferOwnership(userID uint, agentID uint, toWallet string, newKey string, ip string) (*models.Agent, error) {
+	toWallet = strings.ToLower(toWallet)
+
+	return s.update(userID, agentID, func(tx *gorm.DB, agent *models.Agent) error {
+		var target models.User
+		if err := tx.Where("wallet_address = ?", toWallet).First(&target).Error; err != nil {
+			return errors.New("target user not found, they need to log in once first")
+		}
+		if target.ID == userID {
+			return errors.New("cannot transfer to yourself")
+		}
+
+		// 旧主人手里的 Key 作废，新主人通过更换 Key 获取
+		agent.OwnerUserID = &target.ID
+		agent.APIKey = newKey
+		if err := tx.Save(agent).Error; err != nil {
+			return err
+		}
+
+		// 提现钱包需要新主人重新签名绑定
+		if err := tx.Model(&models.AgentTokenBalance{}).
+			Where("agent_id = ?", agent.ID).
+			Update("wallet_verified", false).Error; err != nil {
+			return err
+		}
+
+		return s.log(tx, agent.ID, userID, "transfer", fmt.Sprintf("to user #%d %s", target.ID, toWallet), ip)
+	})
+}
+
+// GetOwnerLogs 获取 Agent 的主人操作记录
+func (s *AgentOwnerService) GetOwnerLogs(userID uint, agentID uint, limit int) ([]models.AgentOwnerLog, error) {
+	if _, err := s.getOwned(s.db, userID, agentID); err != nil {
+		return nil, err
+	}
+
+	var logs []models.AgentOwnerLog
+	err := s.db.Where("agent_id = ?", agentID).Order("created_at desc").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// update 在事务中修改名下的 Agent
+func (s *AgentOwnerService) update(userID uint, agentID uint, fn func(tx *gorm.DB, agent *models.Agent) error) (*models.Agent, error) {
+	var agent *models.Agent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		agent, err = s.getOwned(tx, userID, agentID)
+		if err != nil {
+			return err
+		}
+		return fn(tx, agent)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// getOwned 获取名下的 Agent
+func (s *AgentOwnerService) getOwned(db *gorm.DB, userID uint, agentID uint) (*models.Agent, error) {
+	var agent models.Agent
+	if err := db.Where("id = ? AND owner_user_id = ?", agentID, userID).First(&agent).Error; err != nil {
+		return nil, errors.New("agent not found or not owned by you")
+	}
+	return &agent, nil
+}
+
+// log 记录主人操作
+func (s *AgentOwnerService) log(tx *gorm.DB, agentID uint, userID uint, action string, detail string, ip string) error {
+	return tx.Create(&models.AgentOwnerLog{
+		AgentID: agentID,
+		UserID:  userID,
+		Action:  action,
+		Detail:  detail,
+		IP:      ip,
+	}).Error
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Agent提现钱包绑定规则：
+//   - 只有认领了 Agent 的主人（钱包登录）能绑定或更换，仅凭API Key无法改走资金
+//   - 目标钱包必须对绑定消息签名（消息包含Agent用户名、钱包地址和时间戳，5分钟有效）
+//   - 已绑定验证过的钱包时，更换还需当前钱包对同一消息签名
+//   - 提现只能转到已绑定的钱包
+//   - 首次绑定和更换钱包后都进入冷却期，期间不可提现，给主人留出发现异常的时间
+const payoutWalletMessagePrefix = "Bind payout wallet for FunnyAI agent"
+
+type AgentWalletService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewAgentWalletService(db *gorm.DB, cfg *config.Config) *AgentWalletService {
+	return &AgentWalletService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// PayoutWalletMessage 生成需要签名的绑定消息
+func PayoutWalletMessage(username string, walletAddress string, timestamp int64) string {
+	return fmt.Sprintf("%s @%s\nWallet: %s\nTimestamp: %d", payoutWalletMessagePrefix, username, strings.ToLower(walletAddress), timestamp)
+}
+
+// ValidatePayoutWalletMessage 校验绑定消息内容和时效（签名由调用方校验）
+func (s *AgentWalletService) ValidatePayoutWalletMessage(agent *models.Agent, walletAddress string, message string) error {
+	idx := strings.LastIndex(message, "\nTimestamp: ")
+	if idx < 0 {
+		return errors.New("invalid message format")
+	}
+	timestamp, err := strconv.ParseInt(message[idx+len("\nTimestamp: "):], 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp in message")
+	}
+	if message != PayoutWalletMessage(agent.Username, walletAddress, timestamp) {
+		return errors.New("message does not match agent and wallet")
+	}
+
+	// 5分钟有效期，防止重放
+	now := time.Now().Unix()
+	if now-timestamp > 300 || timestamp-now > 60 {
+		return errors.New("message expired, please request a new one")
+	}
+	return nil
+}
+
+// BindPayoutWallet 主人为名下 Agent 绑定提现钱包（签名已验证）
+// currentWallet 为已验证签名的当前钱包地址（首次绑定时为空），与库中当前钱包不一致时拒绝更换
+func (s *AgentWalletService) BindPayoutWallet(ownerUserID uint, agentID uint, walletAddress string, currentWallet string, message string, signature string, ip string) (*models.AgentTokenBalance, error) {
+	walletAddress = strings.ToLower(walletAddress)
+
+	var balance models.AgentTokenBalance
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 事务内再次确认归属，防止与转让并发
+		if _, err := NewAgentOwnerService(tx, s.cfg).getOwned(tx, ownerUserID, agentID); err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(models.AgentTokenBalance{AgentID: agentID}).
+			Attrs(models.AgentTokenBalance{
+				Balance:        decimal.Zero,
+				LockedBalance:  decimal.Zero,
+				TotalReceived:  decimal.Zero,
+				TotalWithdrawn: decimal.Zero,
+				TotalRewards:   decimal.Zero,
+				VestingBalance: decimal.Zero,
+			}).
+			FirstOrCreate(&balance).Error; err != nil {
+			return err
+		}
+
+		// 同一钱包重复绑定不触发冷却
+		if balance.WalletVerified && strings.EqualFold(balance.WalletAddress, walletAddress) {
+			return nil
+		}
+		if balance.WalletVerified && !strings.EqualFold(balance.WalletAddress, currentWallet) {
+			return errors.New("signature from the current payout wallet is required")
+		}
+
+		now := time.Now()
+		previous := balance.WalletAddress
+		binding := models.AgentWalletBinding{
+			AgentID:        agentID,
+			WalletAddress:  walletAddress,
+			PreviousWallet: previous,
+			Message:        message,
+			Signature:      strings.ToLower(signature),
+			IP:             ip,
+		}
+
+		// 首次绑定和更换都进入冷却期
+		if s.cfg.AgentWalletCooldownHours > 0 {
+			until := now.Add(time.Duration(s.cfg.AgentWalletCooldownHours) * time.Hour)
+			binding.CooldownUntil = &until
+			balance.WithdrawableAt = &until
+		}
+
+		if err := tx.Create(&binding).Error; err != nil {
+			return errors.New("signature already used")
+		}
+
+		balance.WalletAddress = walletAddress
+		balance.WalletVerified = true
+		balance.WalletBoundAt = &now
+		if err := tx.Save(&balance).Error; err != nil {
+			return err
+		}
+		return NewAgentOwnerService(tx, s.cfg).log(tx, agentID, ownerUserID, "bind_payout_wallet", walletAddress, ip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// GetPayoutWallet 获取Agent当前提现钱包
+func (s *AgentWalletService) GetPayoutWallet(agentID uint) (*models.AgentTokenBalance, error) {
+	var balance models.AgentTokenBalance
+	if err := s.db.Where("agent_id = ?", agentID).First(&balance).Error; err != nil {
+		return nil, errors.New("payout wallet not bound")
+	}
+	return &balance, nil
+}
+
+// GetBindingHistory 获取绑定记录
+func (s *AgentWalletService) GetBindingHistory(agentID uint, limit int) ([]models.AgentWalletBinding, error) {
+	var bindings []models.AgentWalletBinding
+	err := s.db.Where("agent_id = ?", agentID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&bindings).Error
+	return bindings, err
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 空投快照规则：
+//   - 得分 = 基础分 + 积分×权重 + 累计打赏积分×权重 + 签到次数×权重 + 最高连签×权重
+//   - 设置了空投总量时按得分比例分配，否则得分即为代币数量
+//   - 单个钱包不超过上限，低于下限的不参与
+//   - 钱包按地址升序排列，排列位置即合约中的 index
+type AirdropService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewAirdropService(db *gorm.DB, cfg *config.Config) *AirdropService {
+	return &AirdropService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// AirdropFormula 空投计算公式
+type AirdropFormula struct {
+	BaseAmount    decimal.Decimal `json:"baseAmount"`    // 每个有积分记录的钱包的基础分
+	PointsWeight  decimal.Decimal `json:"pointsWeight"`  // 当前积分权重
+	TipsWeight    decimal.Decimal `json:"tipsWeight"`    // 累计打赏积分权重（TipRecord）
+	CheckInWeight decimal.Decimal `json:"checkInWeight"` // 签到次数权重（CheckInRecord）
+	StreakWeight  decimal.Decimal `json:"streakWeight"`  // 最高连签天数权重
+	TotalSupply   decimal.Decimal `json:"totalSupply"`   // 空投总量（0=不按比例分配）
+	MinAmount     decimal.Decimal `json:"minAmount"`     // 最低分配量
+	MaxAmount     decimal.Decimal `json:"maxAmount"`     // 单钱包上限（0=不限）
+}
+
+// AirdropProof 单个钱包的领取证明（MerkleDistributor.claim 参数）
+type AirdropProof struct {
+	Snapshot   string   `json:"snapshot"`
+	MerkleRoot string   `json:"merkleRoot"`
+	Contract   string   `json:"contract,omitempty"`
+	Index      uint     `json:"index"`
+	Account    string   `json:"account"`
+	Amount     string   `json:"amount"`    // 十六进制最小单位
+	AmountRaw  string   `json:"amountRaw"` // 十进制最小单位
+	Proof      []string `json:"proof"`
+}
+
+// DistributorClaim / DistributorInfo 与 Uniswap merkle-distributor 生成的 JSON 格式一致
+type DistributorClaim struct {
+	Index  uint     `json:"index"`
+	Amount string   `json:"amount"`
+	Proof  []string `json:"proof"`
+}
+
+type DistributorInfo struct {
+	MerkleRoot string                      `json:"merkleRoot"`
+	TokenTotal string                      `json:"tokenTotal"`
+	Claims     map[string]DistributorClaim `json:"claims"`
+}
+
+// airdropStats 快照时的钱包数据
+type airdropStats struct {
+	WalletAddress string
+	Points        int
+	MaxStreak     int
+	TipsGiven     int
+	CheckIns      int
+}
+
+// CreateSnapshot 生成空投快照：计算分配、构建 Merkle 树并保存证明
+func (s *AirdropService) CreateSnapshot(name string, formula AirdropFormula, tokenDecimals int) (*models.AirdropSnapshot, error) {
+	if name == "" {
+		return nil, errors.New("snapshot name is required")
+	}
+	var existing models.AirdropSnapshot
+	if err := s.db.Where("name = ?", name).First(&existing).Error; err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	stats, err := s.collectStats()
+	if err != nil {
+		return nil, err
+	}
+	allocations := computeAllocations(stats, formula, tokenDecimals)
+	if len(allocations) == 0 {
+		return nil, errors.New("no eligible wallets")
+	}
+
+	// 构建 Merkle 树
+	leaves := make([]common.Hash, len(allocations))
+	for i := range allocations {
+		a := &allocations[i]
+		a.ClaimIndex = uint(i)
+		raw, _ := new(big.Int).SetString(a.AmountRaw, 10)
+		leaves[i] = DistributorLeaf(a.ClaimIndex, common.HexToAddress(a.WalletAddress), raw)
+	}
+	tree := NewMerkleTree(leaves)
+	root := tree.Root()
+
+	total := decimal.Zero
+	for i := range allocations {
+		proof := tree.Proof(i)
+		if !VerifyMerkleProof(leaves[i], proof, root) {
+			return nil, fmt.Errorf("merkle proof verification failed for %s", allocations[i].WalletAddress)
+		}
+		proofJSON, _ := json.Marshal(hashesToHex(proof))
+		allocations[i].Proof = string(proofJSON)
+		total = total.Add(allocations[i].Amount)
+	}
+
+	formulaJSON, _ := json.Marshal(formula)
+	snapshot := models.AirdropSnapshot{
+		Name:          name,
+		MerkleRoot:    root.Hex(),
+		TokenTotal:    total,
+		TokenDecimals: tokenDecimals,
+		WalletCount:   len(allocations),
+		Formula:       string(formulaJSON),
+		IsActive:      true,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&snapshot).Error; err != nil {
+			return err
+		}
+		for i := range allocations {
+			allocations[i].SnapshotID = snapshot.ID
+		}
+		return tx.CreateInBatches(allocations, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetSnapshot 按名称获取快照（名称为空时取最新的有效快照）
+func (s *AirdropService) GetSnapshot(name string) (*models.AirdropSnapshot, error) {
+	var snapshot models.AirdropSnapshot
+	query := s.db.Where("is_active = ?", true)
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if err := query.Order("created_at desc").First(&snapshot).Error; err != nil {
+		return nil, errors.New("airdrop snapshot not found")
+	}
+	return &snapshot, nil
+}
+
+// GetActiveSnapshots 获取对外提供证明的快照
+func (s *AirdropService) GetActiveSnapshots() ([]models.AirdropSnapshot, error) {
+	var snapshots []models.AirdropSnapshot
+	err := s.db.Where("is_active = ?", true).Order("created_at desc").Find(&snapshots).Error
+	return snapshots, err
+}
+
+// GetProof 获取钱包的领取证明
+func (s *AirdropService) GetProof(walletAddress string, snapshotName string) (*AirdropProof, error) {
+	snapshot, err := s.GetSnapshot(snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocation models.AirdropAllocation
+	if err := s.db.Where("snapshot_id = ? AND wallet_address = ?", snapshot.ID, strings.ToLower(walletAddress)).
+		First(&allocation).Error; err != nil {
+		return nil, errors.New("wallet not eligible for this airdrop")
+	}
+
+	return buildAirdropProof(snapshot, &allocation)
+}
+
+// ExportDistributorInfo 导出 merkle-distributor 格式的完整分配表
+func (s *AirdropService) ExportDistributorInfo(snapshotName string) (*DistributorInfo, error) {
+	snapshot, err := s.GetSnapshot(snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []models.AirdropAllocation
+	if err := s.db.Where("snapshot_id = ?", snapshot.ID).Order("claim_index asc").Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+
+	tokenTotal := new(big.Int)
+	info := &DistributorInfo{
+		MerkleRoot: snapshot.MerkleRoot,
+		Claims:     make(map[string]DistributorClaim, len(allocations)),
+	}
+	for i := range allocations {
+		proof, err := buildAirdropProof(snapshot, &allocations[i])
+		if err != nil {
+			return nil, err
+		}
+		raw, _ := new(big.Int).SetString(allocations[i].AmountRaw, 10)
+		tokenTotal.Add(tokenTotal, raw)
+		info.Claims[proof.Account] = DistributorClaim{
+			Index:  proof.Index,
+			Amount: proof.Amount,
+			Proof:  proof.Proof,
+		}
+	}
+	info.TokenTotal = hexutil.EncodeBig(tokenTotal)
+	return info, nil
+}
+
+// collectStats 汇总旧积分系统数据
+func (s *AirdropService) collectStats() ([]airdropStats, error) {
+	statsByWallet := make(map[string]*airdropStats)
+	get := func(wallet string) *airdropStats {
+		wallet = strings.ToLower(wallet)
+		st, ok := statsByWallet[wallet]
+		if !ok {
+			st = &airdropStats{WalletAddress: wallet}
+			statsByWallet[wallet] = st
+		}
+		return st
+	}
+
+	var users []models.User
+	if err := s.db.Select("wallet_address, points, max_streak").
+		Where("wallet_address <> ''").
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		st := get(u.WalletAddress)
+		st.Points = u.Points
+		st.MaxStreak = u.MaxStreak
+	}
+
+	var tips []struct {
+		UserWallet string
+		Total      int
+	}
+	if err := s.db.Model(&models.TipRecord{}).
+		Select("user_wallet, COALESCE(SUM(amount), 0) as total").
+		Group("user_wallet").
+		Scan(&tips).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range tips {
+		get(t.UserWallet).TipsGiven = t.Total
+	}
+
+	var checkIns []struct {
+		UserWallet string
+		Total      int
+	}
+	if err := s.db.Model(&models.CheckInRecord{}).
+		Select("user_wallet, COUNT(*) as total").
+		Group("user_wallet").
+		Scan(&checkIns).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range checkIns {
+		get(c.UserWallet).CheckIns = c.Total
+	}
+
+	stats := make([]airdropStats, 0, len(statsByWallet))
+	for _, st := range statsByWallet {
+		if !common.IsHexAddress(st.WalletAddress) {
+			continue
+		}
+		stats = append(stats, *st)
+	}
+	return stats, nil
+}
+
+// computeAllocations 按公式计算分配（结果按地址升序）
+func computeAllocations(stats []airdropStats, formula AirdropFormula, tokenDecimals int) []models.AirdropAllocation {
+	scores := make([]decimal.Decimal, len(stats))
+	totalScore := decimal.Zero
+	for i, st := range stats {
+		if st.Points == 0 && st.TipsGiven == 0 && st.CheckIns == 0 {
+			continue
+		}
+		score := formula.BaseAmount.
+			Add(formula.PointsWeight.Mul(decimal.NewFromInt(int64(st.Points)))).
+			Add(formula.TipsWeight.Mul(decimal.NewFromInt(int64(st.TipsGiven)))).
+			Add(formula.CheckInWeight.Mul(decimal.NewFromInt(int64(st.CheckIns)))).
+			Add(formula.StreakWeight.Mul(decimal.NewFromInt(int64(st.MaxStreak))))
+		if !score.IsPositive() {
+			continue
+		}
+		scores[i] = score
+		totalScore = totalScore.Add(score)
+	}
+
+	allocations := make([]models.AirdropAllocation, 0, len(stats))
+	for i, st := range stats {
+		if !scores[i].IsPositive() {
+			continue
+		}
+		amount := scores[i]
+		if formula.TotalSupply.IsPositive() {
+			amount = formula.TotalSupply.Mul(scores[i]).Div(totalScore)
+		}
+		if formula.MaxAmount.IsPositive() {
+			amount = decimal.Min(amount, formula.MaxAmount)
+		}
+		// 截断到代币精度，保证与链上最小单位一致
+		amount = amount.Truncate(int32(tokenDecimals))
+		if !amount.IsPositive() || amount.LessThan(formula.MinAmount) {
+			continue
+		}
+
+		allocations = append(allocations, models.AirdropAllocation{
+			WalletAddress: st.WalletAddress,
+			Amount:        amount,
+			AmountRaw:     amount.Shift(int32(tokenDecimals)).BigInt().String(),
+			Points:        st.Points,
+			TipsGiven:     st.TipsGiven,
+			CheckIns:      st.CheckIns,
+			MaxStreak:     st.MaxStreak,
+		})
+	}
+
+	sort.Slice(allocations, func(i, j int) bool {
+		return allocations[i].WalletAddress < allocations[j].WalletAddress
+	})
+	return allocations
+}
+
+// buildAirdropProof 组装领取证明
+func buildAirdropProof(snapshot *models.AirdropSnapshot, allocation *models.AirdropAllocation) (*AirdropProof, error) {
+	raw, ok := new(big.Int).SetString(allocation.AmountRaw, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid allocation amount for %s", allocation.WalletAddress)
+	}
+
+	var proof []string
+	if err := json.Unmarshal([]byte(allocation.Proof), &proof); err != nil {
+		return nil, fmt.Errorf("invalid proof for %s", allocation.WalletAddress)
+	}
+	if proof == nil {
+		proof = []string{}
+	}
+
+	return &AirdropProof{
+		Snapshot:   snapshot.Name,
+		MerkleRoot: snapshot.MerkleRoot,
+		Contract:   snapshot.Contract,
+		Index:      allocation.ClaimIndex,
+		Account:    common.HexToAddress(allocation.WalletAddress).Hex(),
+		Amount:     hexutil.EncodeBig(raw),
+		AmountRaw:  allocation.AmountRaw,
+		Proof:      proof,
+	}, nil
+}
+
+// hashesToHex 哈希列表转十六进制字符串
+func hashesToHex(hashes []common.Hash) []string {
+	result := make([]string, len(hashes))
+	for i, h := range hashes {
+		result[i] = h.Hex()
+	}
+	return result
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 资金流分析：
+//   - 每日汇总按 UTC 日期物化到 analytics_dailies，查询时再按日/周/月聚合
+//   - 后台任务每小时重算最近两天（覆盖跨天和延迟确认的记录），首次运行时回填全部历史
+//   - 查询范围包含今天时先重算今天，保证数据实时
+const (
+	MetricFeeIncome  = "fee_income" // 平台收入（按收入类型）
+	MetricDeposit    = "deposit"    // 已确认充值
+	MetricWithdrawal = "withdrawal" // 已完成提现（按到账金额+手续费）
+	MetricTip        = "tip"        // 代币打赏
+	MetricReward     = "reward"     // 奖励发放（按奖励类型，按实际发放时间计入）
+)
+
+// analyticsMetricSQL 每个指标的汇总 SQL（列：day, dimension, amount, count；参数：起止时间）
+var analyticsMetricSQL = map[string]string{
+	MetricFeeIncome: `SELECT (created_at AT TIME ZONE 'UTC')::date AS day, income_type AS dimension, SUM(amount) AS amount, COUNT(*) AS count
+		FROM platform_incomes WHERE deleted_at IS NULL AND created_at >= ? AND created_at < ? GROUP BY 1, 2`,
+	MetricDeposit: `SELECT (COALESCE(confirmed_at, created_at) AT TIME ZONE 'UTC')::date AS day, '' AS dimension, SUM(amount) AS amount, COUNT(*) AS count
+		FROM deposits WHERE deleted_at IS NULL AND status = 'confirmed' AND COALESCE(confirmed_at, created_at) >= ? AND COALESCE(confirmed_at, created_at) < ? GROUP BY 1, 2`,
+	MetricWithdrawal: `SELECT (COALESCE(processed_at, created_at) AT TIME ZONE 'UTC')::date AS day, user_type AS dimension, SUM(amount) AS amount, COUNT(*) AS count
+		FROM withdrawals WHERE deleted_at IS NULL AND status = 'completed' AND COALESCE(processed_at, created_at) >= ? AND COALESCE(processed_at, created_at) < ? GROUP BY 1, 2`,
+	MetricTip: `SELECT (created_at AT TIME ZONE 'UTC')::date AS day, '' AS dimension, SUM(amount) AS amount, COUNT(*) AS count
+		FROM token_tips WHERE deleted_at IS NULL AND created_at >= ? AND created_at < ? GROUP BY 1, 2`,
+	MetricReward: `SELECT (COALESCE(granted_at, created_at) AT TIME ZONE 'UTC')::date AS day, reward_type AS dimension, SUM(amount) AS amount, COUNT(*) AS count
+		FROM rewards WHERE deleted_at IS NULL AND status = 'granted' AND COALESCE(granted_at, created_at) >= ? AND COALESCE(granted_at, created_at) < ? GROUP BY 1, 2`,
+}
+
+type AnalyticsService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewAnalyticsService(db *gorm.DB, cfg *config.Config) *AnalyticsService {
+	return &AnalyticsService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// AnalyticsBucket 时间序列中的一个时间段
+type AnalyticsBucket struct {
+	Start       time.Time                  `json:"start"`
+	FeeIncome   map[string]decimal.Decimal `json:"feeIncome"` // 按收入类型
+	FeeTotal    decimal.Decimal            `json:"feeTotal"`
+	Deposits    decimal.Decimal            `json:"deposits"`
+	DepositCnt  int64                      `json:"depositCount"`
+	Withdrawals decimal.Decimal            `json:"withdrawals"`
+	WithdrawCnt int64                      `json:"withdrawalCount"`
+	Tips        decimal.Decimal            `json:"tips"`
+	TipCount    int64                      `json:"tipCount"`
+	Rewards     map[string]decimal.Decimal `json:"rewards"` // 按奖励类型
+	RewardTotal decimal.Decimal            `json:"rewardTotal"`
+	NetInflow   decimal.Decimal            `json:"netInflow"` // 充值 - 提现
+}
+
+// RefreshDaily 重算 [from, to) 日期范围内的每日汇总
+func (s *AnalyticsService) RefreshDaily(from time.Time, to time.Time) error {
+	from = truncateDay(from)
+	to = truncateDay(to)
+	if !to.After(from) {
+		to = from.AddDate(0, 0, 1)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("day >= ? AND day < ?", from, to).Delete(&models.AnalyticsDaily{}).Error; err != nil {
+			return err
+		}
+		for metric, query := range analyticsMetricSQL {
+			if err := tx.Exec(`INSERT INTO analytics_dailies (created_at, updated_at, day, metric, dimension, amount, count)
+				SELECT NOW(), NOW(), t.day, ?, COALESCE(t.dimension, ''), COALESCE(t.amount, 0), t.count FROM (`+query+`) t`,
+				metric, from, to).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetTimeSeries 获取按 day/week/month 聚合的时间序列
+func (s *AnalyticsService) GetTimeSeries(bucket string, from time.Time, to time.Time) ([]AnalyticsBucket, error) {
+	if bucket != "day" && bucket != "week" && bucket != "month" {
+		return nil, errors.New("bucket must be day, week or month")
+	}
+	from = truncateDay(from)
+	to = truncateDay(to)
+
+	// 范围包含今天时先刷新今天
+	today := truncateDay(time.Now().UTC())
+	if !today.Before(from) && today.Before(to) {
+		if err := s.RefreshDaily(today, today.AddDate(0, 0, 1)); err != nil {
+			log.Printf("Failed to refresh today's analytics: %v", err)
+		}
+	}
+
+	var rows []struct {
+		Bucket    time.Time
+		Metric    string
+		Dimension string
+		Amount    decimal.Decimal
+		Count     int64
+	}
+	if err := s.db.Raw(`SELECT date_trunc(?, day)::date AS bucket, metric, dimension, SUM(amount) AS amount, SUM(count) AS count
+		FROM analytics_dailies WHERE deleted_at IS NULL AND day >= ? AND day < ?
+		GROUP BY 1, 2, 3 ORDER BY 1`, bucket, from, to).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var result []AnalyticsBucket
+	index := make(map[time.Time]int)
+	for _, r := range rows {
+		i, ok := index[r.Bucket]
+		if !ok {
+			result = append(result, AnalyticsBucket{
+				Start:     r.Bucket,
+				FeeIncome: make(map[string]decimal.Decimal),
+				Rewards:   make(map[string]decimal.Decimal),
+			})
+			i = len(result) - 1
+			index[r.Bucket] = i
+		}
+		b := &result[i]
+
+		switch r.Metric {
+		case MetricFeeIncome:
+			b.FeeIncome[r.Dimension] = b.FeeIncome[r.Dimension].Add(r.Amount)
+			b.FeeTotal = b.FeeTotal.Add(r.Amount)
+		case MetricDeposit:
+			b.Deposits = b.Deposits.Add(r.Amount)
+			b.DepositCnt += r.Count
+		case MetricWithdrawal:
+			b.Withdrawals = b.Withdrawals.Add(r.Amount)
+			b.WithdrawCnt += r.Count
+		case MetricTip:
+			b.Tips = b.Tips.Add(r.Amount)
+			b.TipCount += r.Count
+		case MetricReward:
+			b.Rewards[r.Dimension] = b.Rewards[r.Dimension].Add(r.Amount)
+			b.RewardTotal = b.RewardTotal.Add(r.Amount)
+		}
+	}
+	for i := range result {
+		result[i].NetInflow = result[i].Deposits.Sub(result[i].Withdrawals)
+	}
+	if result == nil {
+		result = []AnalyticsBucket{}
+	}
+	return result, nil
+}
+
+// StartAnalyticsAggregator 定期物化每日汇总（在单独goroutine中运行）
+func (s *AnalyticsService) StartAnalyticsAggregator(ctx context.Context) {
+	s.aggregate()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Analytics aggregator stopped")
+			return
+		case <-ticker.C:
+			s.aggregate()
+		}
+	}
+}
+
+// aggregate 首次回填全部历史，之后重算最近两天
+func (s *AnalyticsService) aggregate() {
+	tomorrow := truncateDay(time.Now().UTC()).AddDate(0, 0, 1)
+
+	var latest models.AnalyticsDaily
+	from := tomorrow.AddDate(0, 0, -2)
+	if err := s.db.Order("day desc").First(&latest).Error; err != nil {
+		from = s.earliestActivity()
+	}
+
+	if err := s.RefreshDaily(from, tomorrow); err != nil {
+		log.Printf("Failed to refresh analytics: %v", err)
+	}
+}
+
+// earliestActivity 最早一条资金流水的日期
+func (s *AnalyticsService) earliestActivity() time.Time {
+	var earliest *time.Time
+	s.db.Raw(`SELECT MIN(t) FROM (
+		SELECT MIN(created_at) AS t FROM platform_incomes
+		UNION ALL SELECT MIN(created_at) FROM deposits
+		UNION ALL SELECT MIN(created_at) FROM withdrawals
+		UNION ALL SELECT MIN(created_at) FROM token_tips
+		UNION ALL SELECT MIN(created_at) FROM rewards
+	) m`).Scan(&earliest)
+	if earliest == nil {
+		return truncateDay(time.Now().UTC())
+	}
+	return truncateDay(earliest.UTC())
+}
+
+// truncateDay 截断到 UTC 日期
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// AdminActor 管理操作的执行人
+type AdminActor struct {
+	Wallet string
+	IP     string
+}
+
+// RecordAudit 记录管理员操作（在业务事务中调用，保证变更与审计记录一致）
+func RecordAudit(tx *gorm.DB, actor AdminActor, action string, targetType string, targetID string, before interface{}, after interface{}) error {
+	entry := models.AdminAuditLog{
+		AdminWallet: actor.Wallet,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Before:      auditJSON(before),
+		After:       auditJSON(after),
+		IP:          actor.IP,
+	}
+	return tx.Create(&entry).Error
+}
+
+// GetAuditLogs 查询审计记录
+func GetAuditLogs(db *gorm.DB, targetType string, targetID string, limit int, offset int) ([]models.AdminAuditLog, int64, error) {
+	var logs []models.AdminAuditLog
+	var total int64
+
+	query := db.Model(&models.AdminAuditLog{})
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	query.Count(&total)
+
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&logs).Error
+	return logs, total, err
+}
+
+func auditJSON(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
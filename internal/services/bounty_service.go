@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 悬赏流程：
+//   - 发布时奖金从可用余额转入 EscrowBalance 托管
+//   - Agent 在回答截止前通过发帖接口（带 bountyId）回答，每个 Agent 只能回答一次，不能回答自己主人的悬赏
+//   - 发布者在评选截止前选出一个或多个获胜回答（多个时平分）
+//   - 评选截止后仍未选出时按 ExpireMode 自动退回或平分给所有回答；无人回答时回答截止后直接退回
+//   - 付给 Agent 时按打赏费率（分档/Agent专属/质押折扣）抽成，记为 bounty_fee 平台收入
+type BountyService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewBountyService(db *gorm.DB, cfg *config.Config) *BountyService {
+	return &BountyService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// BountyInput 发布悬赏参数
+type BountyInput struct {
+	Title          string
+	Prompt         string
+	Category       string
+	Amount         decimal.Decimal
+	AnswerDeadline time.Time
+	ExpireMode     string
+}
+
+// CreateBounty 发布悬赏（奖金转入托管）
+func (s *BountyService) CreateBounty(walletAddress string, input BountyInput) (*models.Bounty, error) {
+	walletAddress = strings.ToLower(walletAddress)
+	input.Title = strings.TrimSpace(input.Title)
+	input.Prompt = strings.TrimSpace(input.Prompt)
+
+	if input.Title == "" || utf8.RuneCountInString(input.Title) > 100 {
+		return nil, errors.New("title is required and must be at most 100 characters")
+	}
+	if input.Prompt == "" || utf8.RuneCountInString(input.Prompt) > 2000 {
+		return nil, errors.New("prompt is required and must be at most 2000 characters")
+	}
+	minAmount := decimal.NewFromFloat(s.cfg.MinBountyAmount)
+	if input.Amount.LessThan(minAmount) {
+		return nil, fmt.Errorf("minimum bounty amount is %s", minAmount.String())
+	}
+	now := time.Now()
+	if input.AnswerDeadline.Before(now.Add(time.Hour)) {
+		return nil, errors.New("answer deadline must be at least 1 hour from now")
+	}
+	if input.AnswerDeadline.After(now.AddDate(0, 0, s.cfg.MaxBountyDays)) {
+		return nil, fmt.Errorf("answer deadline must be within %d days", s.cfg.MaxBountyDays)
+	}
+	if input.ExpireMode == "" {
+		input.ExpireMode = "refund"
+	}
+	if input.ExpireMode != "refund" && input.ExpireMode != "split" {
+		return nil, errors.New("expireMode must be refund or split")
+	}
+
+	bounty := &models.Bounty{
+		CreatorWallet:  walletAddress,
+		Title:          input.Title,
+		Prompt:         input.Prompt,
+		Category:       input.Category,
+		Amount:         input.Amount,
+		AnswerDeadline: input.AnswerDeadline,
+		JudgeDeadline:  input.AnswerDeadline.Add(time.Duration(s.cfg.BountyJudgeHours) * time.Hour),
+		ExpireMode:     input.ExpireMode,
+		Status:         "open",
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新，防止并发超额托管
+		result := tx.Model(&models.TokenBalance{}).
+			Where("wallet_address = ? AND balance >= ?", walletAddress, input.Amount).
+			UpdateColumns(map[string]interface{}{
+				"balance":        gorm.Expr("balance - ?", input.Amount),
+				"escrow_balance": gorm.Expr("escrow_balance + ?", input.Amount),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("insufficient balance")
+		}
+		return tx.Create(bounty).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bounty, nil
+}
+
+// ListBounties 获取悬赏列表（status 为空时返回全部）
+func (s *BountyService) ListBounties(status string, creatorWallet string, limit int, offset int) ([]models.Bounty, int64, error) {
+	var bounties []models.Bounty
+	var total int64
+
+	query := s.db.Model(&models.Bounty{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if creatorWallet != "" {
+		query = query.Where("creator_wallet = ?", strings.ToLower(creatorWallet))
+	}
+	query.Count(&total)
+
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&bounties).Error
+	return bounties, total, err
+}
+
+// GetBounty 获取悬赏详情（含回答）
+func (s *BountyService) GetBounty(id uint) (*models.Bounty, error) {
+	var bounty models.Bounty
+	err := s.db.Preload("Answers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("is_winner desc, created_at asc")
+	}).Preload("Answers.Post").Preload("Answers.Post.Agent").First(&bounty, id).Error
+	if err != nil {
+		return nil, errors.New("bounty not found")
+	}
+	return &bounty, nil
+}
+
+// CheckAnswerable 检查 Agent 能否回答（发帖前调用，避免产生无效帖子）
+func (s *BountyService) CheckAnswerable(bountyID uint, agentID uint) (*models.Bounty, error) {
+	var bounty models.Bounty
+	if err := s.db.First(&bounty, bountyID).Error; err != nil {
+		return nil, errors.New("bounty not found")
+	}
+	if bounty.Status != "open" || !time.Now().Before(bounty.AnswerDeadline) {
+		return nil, errors.New("bounty is no longer accepting answers")
+	}
+
+	var count int64
+	s.db.Model(&models.BountyAnswer{}).Where("bounty_id = ? AND agent_id = ?", bountyID, agentID).Count(&count)
+	if count > 0 {
+		return nil, errors.New("agent has already answered this bounty")
+	}
+
+	// 不能回答自己主人的悬赏
+	var owner models.User
+	if err := s.db.Joins("JOIN agents ON agents.owner_user_id = users.id").
+		Where("agents.id = ?", agentID).First(&owner).Error; err == nil &&
+		strings.EqualFold(owner.WalletAddress, bounty.CreatorWallet) {
+		return nil, errors.New("agent cannot answer its owner's bounty")
+	}
+	return &bounty, nil
+}
+
+// SubmitAnswer 记录回答（在发帖事务中调用）
+func (s *BountyService) SubmitAnswer(tx *gorm.DB, bountyID uint, agentID uint, postID uint) error {
+	result := tx.Model(&models.Bounty{}).
+		Where("id = ? AND status = ? AND answer_deadline > ?", bountyID, "open", time.Now()).
+		UpdateColumn("answers_count", gorm.Expr("answers_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("bounty is no longer accepting answers")
+	}
+
+	if err := tx.Create(&models.BountyAnswer{
+		BountyID: bountyID,
+		AgentID:  agentID,
+		PostID:   postID,
+	}).Error; err != nil {
+		return errors.New("agent has already answered this bounty")
+	}
+	return tx.Model(&models.Post{}).Where("id = ?", postID).Update("bounty_id", bountyID).Error
+}
+
+// Award 发布者选出获胜回答（多个时平分）
+func (s *BountyService) Award(walletAddress string, bountyID uint, postIDs []uint) (*models.Bounty, error) {
+	if len(postIDs) == 0 {
+		return nil, errors.New("at least one winning post is required")
+	}
+
+	var bounty models.Bounty
+	if err := s.db.Where("id = ? AND creator_wallet = ?", bountyID, strings.ToLower(walletAddress)).First(&bounty).Error; err != nil {
+		return nil, errors.New("bounty not found")
+	}
+	if bounty.Status != "open" {
+		return nil, errors.New("bounty already settled")
+	}
+	if !time.Now().Before(bounty.JudgeDeadline) {
+		return nil, errors.New("judging deadline has passed")
+	}
+
+	var winners []models.BountyAnswer
+	if err := s.db.Where("bounty_id = ? AND post_id IN ?", bountyID, postIDs).Find(&winners).Error; err != nil {
+		return nil, err
+	}
+	if len(winners) != len(postIDs) {
+		return nil, errors.New("all winning posts must be answers to this bounty")
+	}
+
+	status := "awarded"
+	if len(winners) > 1 {
+		status = "split"
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return s.payout(tx, &bounty, winners, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetBounty(bountyID)
+}
+
+// Cancel 发布者取消悬赏（仅限无人回答时）
+func (s *BountyService) Cancel(walletAddress string, bountyID uint) (*models.Bounty, error) {
+	var bounty models.Bounty
+	if err := s.db.Where("id = ? AND creator_wallet = ?", bountyID, strings.ToLower(walletAddress)).First(&bounty).Error; err != nil {
+		return nil, errors.New("bounty not found")
+	}
+	if bounty.AnswersCount > 0 {
+		return nil, errors.New("cannot cancel a bounty that already has answers")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新同时检查回答数，防止与并发提交的回答冲突
+		now := time.Now()
+		result := tx.Model(&models.Bounty{}).
+			Where("id = ? AND status = ? AND answers_count = 0", bounty.ID, "open").
+			Updates(map[string]interface{}{"status": "cancelled", "settled_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("bounty already has answers or is settled")
+		}
+		bounty.Status = "cancelled"
+		bounty.SettledAt = &now
+		return s.releaseEscrow(tx, &bounty, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bounty, nil
+}
+
+// ==================== 自动结算 ====================
+
+// StartBountySettler 定期结算过期悬赏（在单独goroutine中运行）
+func (s *BountyService) StartBountySettler(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Bounty settler stopped")
+			return
+		case <-ticker.C:
+			s.SettleExpired()
+		}
+	}
+}
+
+// SettleExpired 结算过期悬赏
+func (s *BountyService) SettleExpired() {
+	now := time.Now()
+
+	var bounties []models.Bounty
+	s.db.Where("status = ? AND (judge_deadline <= ? OR (answer_deadline <= ? AND answers_count = 0))", "open", now, now).
+		Limit(100).Find(&bounties)
+
+	for i := range bounties {
+		bounty := &bounties[i]
+
+		var answers []models.BountyAnswer
+		if bounty.ExpireMode == "split" {
+			s.db.Where("bounty_id = ?", bounty.ID).Find(&answers)
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if len(answers) > 0 {
+				return s.payout(tx, bounty, answers, "split")
+			}
+			return s.refund(tx, bounty, "refunded")
+		})
+		if err != nil && len(answers) > 0 {
+			// 平分失败（如每份不足最低手续费）时退回
+			log.Printf("Failed to split bounty %d, refunding: %v", bounty.ID, err)
+			err = s.db.Transaction(func(tx *gorm.DB) error {
+				return s.refund(tx, bounty, "refunded")
+			})
+		}
+		if err != nil {
+			log.Printf("Failed to settle bounty %d: %v", bounty.ID, err)
+		}
+	}
+}
+
+// payout 从托管中向获胜回答付款
+func (s *BountyService) payout(tx *gorm.DB, bounty *models.Bounty, winners []models.BountyAnswer, status string) error {
+	if err := s.markSettled(tx, bounty, status); err != nil {
+		return err
+	}
+	if err := s.releaseEscrow(tx, bounty, false); err != nil {
+		return err
+	}
+
+	n := int64(len(winners))
+	share := bounty.Amount.Div(decimal.NewFromInt(n)).Truncate(18)
+	now := time.Now()
+	totalFee := decimal.Zero
+
+	for i, answer := range winners {
+		amount := share
+		if int64(i) == n-1 {
+			// 最后一份补齐舍入误差
+			amount = bounty.Amount.Sub(share.Mul(decimal.NewFromInt(n - 1)))
+		}
+
+		quote, err := payAgent(tx, s.cfg, FeeInput{
+			FeeType:     FeeTypeTip,
+			PayerType:   "user",
+			PayerWallet: bounty.CreatorWallet,
+			AgentID:     answer.AgentID,
+			Amount:      amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.BountyAnswer{}).Where("id = ?", answer.ID).Updates(map[string]interface{}{
+			"is_winner":      true,
+			"payout":         amount,
+			"platform_fee":   quote.Fee,
+			"agent_received": quote.NetAmount,
+			"paid_at":        now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := recordPlatformIncome(tx, "bounty_fee", quote.Fee, "bounty_answer", answer.ID); err != nil {
+			return err
+		}
+		totalFee = totalFee.Add(quote.Fee)
+	}
+
+	bounty.PlatformFee = totalFee
+	return tx.Model(bounty).Update("platform_fee", totalFee).Error
+}
+
+// refund 托管退回发布者
+func (s *BountyService) refund(tx *gorm.DB, bounty *models.Bounty, status string) error {
+	if err := s.markSettled(tx, bounty, status); err != nil {
+		return err
+	}
+	return s.releaseEscrow(tx, bounty, true)
+}
+
+// markSettled 条件更新状态，防止重复结算
+func (s *BountyService) markSettled(tx *gorm.DB, bounty *models.Bounty, status string) error {
+	now := time.Now()
+	result := tx.Model(&models.Bounty{}).
+		Where("id = ? AND status = ?", bounty.ID, "open").
+		Updates(map[string]interface{}{"status": status, "settled_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("bounty already settled")
+	}
+	bounty.Status = status
+	bounty.SettledAt = &now
+	return nil
+}
+
+// releaseEscrow 扣除托管（toBalance 为 true 时退回可用余额）
+func (s *BountyService) releaseEscrow(tx *gorm.DB, bounty *models.Bounty, toBalance bool) error {
+	updates := map[string]interface{}{
+		"escrow_balance": gorm.Expr("escrow_balance - ?", bounty.Amount),
+	}
+	if toBalance {
+		updates["balance"] = gorm.Expr("balance + ?", bounty.Amount)
+	} else {
+		updates["total_tipped"] = gorm.Expr("total_tipped + ?", bounty.Amount)
+	}
+
+	result := tx.Model(&models.TokenBalance{}).
+		Where("wallet_address = ? AND escrow_balance >= ?", bounty.CreatorWallet, bounty.Amount).
+		UpdateColumns(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("escrow balance mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 活动规则：
+//   - 奖励发放时自动匹配进行中的活动，多个命中时取倍数最高的
+//   - 加成部分 = 基础奖励 × (倍数-1)，和基础奖励一起从该奖励类型的激励池发放，
+//     但受活动预算限制，预算不足时只发剩余部分，预算用完活动自动失效
+//   - 分类/Agent 筛选按奖励关联的帖子判断（签到等无关联帖子的奖励只匹配不限分类/Agent的活动）
+//   - 风控延迟发放的奖励不参与活动
+type CampaignService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewCampaignService(db *gorm.DB, cfg *config.Config) *CampaignService {
+	return &CampaignService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// GetActiveCampaigns 获取进行中的活动
+func (s *CampaignService) GetActiveCampaigns() ([]models.RewardCampaign, error) {
+	now := time.Now()
+	var campaigns []models.RewardCampaign
+	err := s.db.Where("is_active = ? AND start_at <= ? AND end_at > ? AND spent < budget", true, now, now).
+		Order("end_at asc").
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
+// FindCampaign 查找适用于本次奖励的活动（倍数最高者）
+func (s *CampaignService) FindCampaign(rewardType string, referenceType string, referenceID uint, recipientType string, recipientID uint) *models.RewardCampaign {
+	campaigns, err := s.GetActiveCampaigns()
+	if err != nil || len(campaigns) == 0 {
+		return nil
+	}
+
+	category, agentID := s.resolveRewardContext(referenceType, referenceID, recipientType, recipientID)
+
+	var best *models.RewardCampaign
+	for i := range campaigns {
+		c := &campaigns[i]
+		if !matchList(c.RewardTypes, rewardType) {
+			continue
+		}
+		if c.Categories != "" && !matchList(c.Categories, category) {
+			continue
+		}
+		if c.AgentIDs != "" && (agentID == 0 || !matchList(c.AgentIDs, strconv.FormatUint(uint64(agentID), 10))) {
+			continue
+		}
+		if best == nil || c.Multiplier.GreaterThan(best.Multiplier) {
+			best = c
+		}
+	}
+	return best
+}
+
+// ReserveBonus 在发放事务中占用活动预算，返回实际加成金额
+func (s *CampaignService) ReserveBonus(tx *gorm.DB, campaign *models.RewardCampaign, baseAmount decimal.Decimal) (decimal.Decimal, error) {
+	bonus := baseAmount.Mul(campaign.Multiplier.Sub(decimal.NewFromInt(1))).Round(18)
+	if !bonus.IsPositive() {
+		return decimal.Zero, nil
+	}
+
+	var current models.RewardCampaign
+	if err := tx.First(&current, campaign.ID).Error; err != nil {
+		return decimal.Zero, err
+	}
+	remaining := current.Budget.Sub(current.Spent)
+	if !remaining.IsPositive() {
+		return decimal.Zero, nil
+	}
+	bonus = decimal.Min(bonus, remaining)
+
+	// 条件更新防止并发超发
+	result := tx.Model(&models.RewardCampaign{}).
+		Where("id = ? AND spent + ? <= budget", campaign.ID, bonus).
+		UpdateColumn("spent", gorm.Expr("spent + ?", bonus))
+	if result.Error != nil {
+		return decimal.Zero, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return decimal.Zero, nil
+	}
+	return bonus, nil
+}
+
+// resolveRewardContext 找到奖励关联帖子的分类和Agent
+func (s *CampaignService) resolveRewardContext(referenceType string, referenceID uint, recipientType string, recipientID uint) (string, uint) {
+	var postID uint
+	switch referenceType {
+	case "post":
+		postID = referenceID
+	case "comment":
+		var comment models.Comment
+		if err := s.db.Select("post_id").First(&comment, referenceID).Error; err == nil {
+			postID = comment.PostID
+		}
+	case "tip":
+		var tip models.TokenTip
+		if err := s.db.Select("post_id").First(&tip, referenceID).Error; err == nil {
+			postID = tip.PostID
+		}
+	}
+
+	if postID != 0 {
+		var post models.Post
+		if err := s.db.Select("category, agent_id").First(&post, postID).Error; err == nil {
+			return post.Category, post.AgentID
+		}
+	}
+	if recipientType == "agent" {
+		return "", recipientID
+	}
+	return "", 0
+}
+
+// ==================== 活动管理（管理员）====================
+
+// CampaignInput 创建/修改活动参数（修改时 nil 表示不修改）
+type CampaignInput struct {
+	Name        *string
+	Description *string
+	StartAt     *time.Time
+	EndAt       *time.Time
+	RewardTypes []string
+	Categories  []string
+	AgentIDs    []uint
+	Multiplier  *decimal.Decimal
+	Budget      *decimal.Decimal
+	IsActive    *bool
+}
+
+// ListCampaigns 获取所有活动
+func (s *CampaignService) ListCampaigns(limit int, offset int) ([]models.RewardCampaign, int64, error) {
+	var campaigns []models.RewardCampaign
+	var total int64
+
+	query := s.db.Model(&models.RewardCampaign{})
+	query.Count(&total)
+
+	err := query.Order("start_at desc").Limit(limit).Offset(offset).Find(&campaigns).Error
+	return campaigns, total, err
+}
+
+// CreateCampaign 创建活动
+func (s *CampaignService) CreateCampaign(input CampaignInput, actor AdminActor) (*models.RewardCampaign, error) {
+	if input.Name == nil || input.StartAt == nil || input.EndAt == nil || input.Multiplier == nil || input.Budget == nil {
+		return nil, errors.New("name, startAt, endAt, multiplier and budget are required")
+	}
+
+	campaign := models.RewardCampaign{
+		Spent:    decimal.Zero,
+		IsActive: true,
+	}
+	if err := applyCampaignInput(&campaign, input); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&campaign).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, "campaign.create", "reward_campaign", fmt.Sprint(campaign.ID), nil, campaign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// UpdateCampaign 修改活动
+func (s *CampaignService) UpdateCampaign(id uint, input CampaignInput, actor AdminActor) (*models.RewardCampaign, error) {
+	var campaign models.RewardCampaign
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&campaign, id).Error; err != nil {
+			return errors.New("campaign not found")
+		}
+		before := campaign
+
+		if err := applyCampaignInput(&campaign, input); err != nil {
+			return err
+		}
+		if err := tx.Save(&campaign).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, "campaign.update", "reward_campaign", fmt.Sprint(campaign.ID), before, campaign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// applyCampaignInput 校验并应用活动参数
+func applyCampaignInput(campaign *models.RewardCampaign, input CampaignInput) error {
+	if input.Name != nil {
+		campaign.Name = *input.Name
+	}
+	if input.Description != nil {
+		campaign.Description = *input.Description
+	}
+	if input.StartAt != nil {
+		campaign.StartAt = *input.StartAt
+	}
+	if input.EndAt != nil {
+		campaign.EndAt = *input.EndAt
+	}
+	if input.RewardTypes != nil {
+		campaign.RewardTypes = strings.Join(input.RewardTypes, ",")
+	}
+	if input.Categories != nil {
+		campaign.Categories = strings.Join(input.Categories, ",")
+	}
+	if input.AgentIDs != nil {
+		ids := make([]string, 0, len(input.AgentIDs))
+		for _, id := range input.AgentIDs {
+			ids = append(ids, strconv.FormatUint(uint64(id), 10))
+		}
+		campaign.AgentIDs = strings.Join(ids, ",")
+	}
+	if input.Multiplier != nil {
+		campaign.Multiplier = *input.Multiplier
+	}
+	if input.Budget != nil {
+		campaign.Budget = *input.Budget
+	}
+	if input.IsActive != nil {
+		campaign.IsActive = *input.IsActive
+	}
+
+	if campaign.Name == "" {
+		return errors.New("name is required")
+	}
+	if !campaign.EndAt.After(campaign.StartAt) {
+		return errors.New("endAt must be after startAt")
+	}
+	if campaign.Multiplier.LessThanOrEqual(decimal.NewFromInt(1)) || campaign.Multiplier.GreaterThan(decimal.NewFromInt(10)) {
+		return errors.New("multiplier must be greater than 1 and at most 10")
+	}
+	if campaign.Budget.IsNegative() || campaign.Budget.LessThan(campaign.Spent) {
+		return errors.New("budget must not be less than spent")
+	}
+	return nil
+}
+
+// matchList 逗号分隔列表是否包含某值（空列表视为全部匹配）
+func matchList(list string, value string) bool {
+	if list == "" {
+		return true
+	}
+	for _, item := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// 楼中楼评论：
+//   - 回复通过 ParentID 挂在父评论下，Depth = 父评论 Depth + 1，不超过 CommentMaxDepth
+//   - 父评论的 RepliesCount 只统计直接回复，帖子的 CommentsCount 统计全部评论和回复
+//   - 评论列表只返回顶层评论；thread 接口用递归 CTE 一次取出整棵子树（按层级截断到 CommentThreadLimit 条）
+//   - 排序：top 按点赞数，new 按时间倒序
+//   - 媒体：最多 CommentMaxImages 张图片或一个视频，必须先经 /upload 上传；
+//     时长无法在服务端可靠获取，视频只按上传大小限制（MaxVideoUploadSize）
+type CommentService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewCommentService(db *gorm.DB, cfg *config.Config) *CommentService {
+	return &CommentService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// CommentInput 发表评论的参数（UserID 与 AgentID 二选一）
+type CommentInput struct {
+	PostID        uint
+	ParentID      *uint
+	UserID        *uint
+	AgentID       *uint
+	WalletAddress string
+	Content       string
+	Images        []string // 图片 URL（上传接口返回）
+	VideoURL      string   // 视频 URL（与图片二选一）
+}
+
+// PreloadComment 评论返回时附带的作者和媒体
+func PreloadComment(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").Preload("Agent").
+		Preload("Images", func(db *gorm.DB) *gorm.DB { return db.Order("order_num") }).
+		Preload("Videos")
+}
+
+// Create 发表评论或回复，并更新帖子评论数和父评论回复数
+func (s *CommentService) Create(input CommentInput) (*models.Comment, error) {
+	var post models.Post
+	if err := s.db.Select("id").First(&post, input.PostID).Error; err != nil {
+		return nil, errors.New("post not found")
+	}
+	if err := s.validateMedia(input); err != nil {
+		return nil, err
+	}
+
+	comment := models.Comment{
+		PostID:        input.PostID,
+		UserID:        input.UserID,
+		AgentID:       input.AgentID,
+		WalletAddress: input.WalletAddress,
+		Content:       input.Content,
+	}
+	if input.ParentID != nil {
+		var parent models.Comment
+		if err := s.db.First(&parent, *input.ParentID).Error; err != nil || parent.PostID != input.PostID {
+			return nil, errors.New("parent comment not found")
+		}
+		if parent.Depth+1 > s.cfg.CommentMaxDepth {
+			return nil, errors.New("reply nesting is too deep")
+		}
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		for i, url := range input.Images {
+			if err := tx.Create(&models.CommentImage{CommentID: comment.ID, URL: url, OrderNum: i}).Error; err != nil {
+				return err
+			}
+		}
+		if input.VideoURL != "" {
+			video := models.CommentVideo{CommentID: comment.ID, URL: input.VideoURL}
+			if err := tx.Create(&video).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Post{}).Where("id = ?", comment.PostID).
+			UpdateColumn("comments_count", gorm.Expr("comments_count + 1")).Error; err != nil {
+			return err
+		}
+		if comment.ParentID != nil {
+			return tx.Model(&models.Comment{}).Where("id = ?", *comment.ParentID).
+				UpdateColumn("replies_count", gorm.Expr("replies_count + 1")).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// Thread 取出以 commentID 为根、最多 depth 层的评论树
+func (s *CommentService) Thread(commentID uint, depth int, sortBy string, viewerID uint) (*models.Comment, error) {
+	if depth < 0 || depth > s.cfg.CommentMaxDepth {
+		depth = s.cfg.CommentMaxDepth
+	}
+	order := "created_at DESC"
+	if sortBy == "top" {
+		order = "likes_count DESC, created_at ASC"
+	}
+
+	var ids []uint
+	err := s.db.Raw(`WITH RECURSIVE thread AS (
+			SELECT id, 0 AS level, likes_count, created_at FROM comments WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, t.level + 1, c.likes_count, c.created_at FROM comments c
+			JOIN thread t ON c.parent_id = t.id
+			WHERE c.deleted_at IS NULL AND t.level < ?
+		) SELECT id FROM thread ORDER BY level, `+order+` LIMIT ?`,
+		commentID, depth, s.cfg.CommentThreadLimit).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("comment not found")
+	}
+
+	var comments []models.Comment
+	if err := s.db.Scopes(PreloadComment).Where("id IN ?", ids).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	s.MarkLiked(comments, viewerID)
+
+	byID := make(map[uint]models.Comment, len(comments))
+	children := make(map[uint][]uint)
+	for _, c := range comments {
+		byID[c.ID] = c
+		if c.ParentID != nil && c.ID != commentID {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	for _, list := range children {
+		sortComments(list, byID, sortBy)
+	}
+
+	var build func(id uint) models.Comment
+	build = func(id uint) models.Comment {
+		c := byID[id]
+		for _, childID := range children[id] {
+			c.Replies = append(c.Replies, build(childID))
+		}
+		return c
+	}
+	root := build(commentID)
+	return &root, nil
+}
+
+// validateMedia 校验评论附带的图片/视频
+func (s *CommentService) validateMedia(input CommentInput) error {
+	if len(input.Images) > 0 && input.VideoURL != "" {
+		return errors.New("a comment can have images or a video, not both")
+	}
+	if len(input.Images) > s.cfg.CommentMaxImages {
+		return fmt.Errorf("a comment can have at most %d images", s.cfg.CommentMaxImages)
+	}
+	if err := validateUploads(s.db, input.Images, false); err != nil {
+		return err
+	}
+	if input.VideoURL != "" {
+		return validateUploads(s.db, []string{input.VideoURL}, true)
+	}
+	return nil
+}
+
+// MarkLiked 标记当前用户已点赞的评论（游客 viewerID 为 0）
+func (s *CommentService) MarkLiked(comments []models.Comment, viewerID uint) {
+	if viewerID == 0 || len(comments) == 0 {
+		return
+	}
+	ids := make([]uint, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+	var liked []uint
+	s.db.Model(&models.CommentLike{}).Where("user_id = ? AND comment_id IN ?", viewerID, ids).Pluck("comment_id", &liked)
+	likedSet := make(map[uint]bool, len(liked))
+	for _, id := range liked {
+		likedSet[id] = true
+	}
+	for i := range comments {
+		comments[i].Liked = likedSet[comments[i].ID]
+	}
+}
+
+// sortComments 同一父评论下的回复排序
+func sortComments(ids []uint, byID map[uint]models.Comment, sortBy string) {
+	sort.SliceStable(ids, func(i, j int) bool {
+		a, b := byID[ids[i]], byID[ids[j]]
+		if sortBy == "top" && a.LikesCount != b.LikesCount {
+			return a.LikesCount > b.LikesCount
+		}
+		if sortBy == "top" {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 手续费类型
+const (
+	FeeTypeTip      = "tip"
+	FeeTypeWithdraw = "withdraw"
+)
+
+// 手续费规则类型
+const (
+	FeeRuleTier   = "tier"
+	FeeRuleAgent  = "agent"
+	FeeRuleStaked = "staked"
+)
+
+// 手续费计算顺序：
+//  1. Agent 专属费率（打赏看收款 Agent，提现看提现 Agent）命中时直接使用，不叠加折扣和上下限
+//  2. 否则按付款方近 FeeVolumeDays 天的同类交易量匹配分档（没有分档规则时使用环境变量中的默认费率）
+//  3. 用户付款时按质押量叠加折扣
+//  4. 应用分档的最低/最高手续费，手续费必须小于金额
+type FeeService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewFeeService(db *gorm.DB, cfg *config.Config) *FeeService {
+	return &FeeService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// FeeInput 手续费计算参数
+type FeeInput struct {
+	FeeType     string          // tip/withdraw
+	PayerType   string          // user/agent
+	PayerID     uint            // 付款 Agent ID（PayerType=agent）
+	PayerWallet string          // 付款用户钱包（PayerType=user）
+	AgentID     uint            // 打赏的收款 Agent / 提现的 Agent
+	Amount      decimal.Decimal // 交易金额（手续费从中扣除）
+}
+
+// FeeQuote 手续费报价
+type FeeQuote struct {
+	FeeType       string          `json:"feeType"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	NetAmount     decimal.Decimal `json:"netAmount"`
+	Rate          decimal.Decimal `json:"rate"`     // 折扣后的实际费率
+	BaseRate      decimal.Decimal `json:"baseRate"` // 折扣前费率
+	Volume        decimal.Decimal `json:"volume"`   // 统计窗口内的交易量
+	TierRuleID    *uint           `json:"tierRuleId,omitempty"`
+	AgentRuleID   *uint           `json:"agentRuleId,omitempty"`
+	StakedBalance decimal.Decimal `json:"stakedBalance"`
+	Discount      decimal.Decimal `json:"discount"` // 质押折扣比例
+	MinFee        decimal.Decimal `json:"minFee"`
+	MaxFee        decimal.Decimal `json:"maxFee"`
+}
+
+// Quote 计算手续费（db 可以是事务）
+func (s *FeeService) Quote(db *gorm.DB, input FeeInput) (*FeeQuote, error) {
+	if input.FeeType != FeeTypeTip && input.FeeType != FeeTypeWithdraw {
+		return nil, errors.New("invalid fee type")
+	}
+	if input.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, errors.New("amount must be positive")
+	}
+	input.PayerWallet = strings.ToLower(input.PayerWallet)
+
+	quote := &FeeQuote{
+		FeeType:  input.FeeType,
+		Amount:   input.Amount,
+		BaseRate: s.defaultRate(input.FeeType),
+	}
+
+	agentRule, err := s.findAgentRule(db, input.FeeType, input.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if agentRule != nil {
+		quote.AgentRuleID = &agentRule.ID
+		quote.BaseRate = agentRule.Rate
+		quote.Rate = agentRule.Rate
+	} else {
+		quote.Volume = s.recentVolume(db, input)
+
+		tier, err := s.findRule(db, input.FeeType, FeeRuleTier, quote.Volume)
+		if err != nil {
+			return nil, err
+		}
+		if tier != nil {
+			quote.TierRuleID = &tier.ID
+			quote.BaseRate = tier.Rate
+			quote.MinFee = tier.MinFee
+			quote.MaxFee = tier.MaxFee
+		}
+
+		quote.Rate = quote.BaseRate
+		if input.PayerType == "user" {
+			quote.StakedBalance = s.stakedBalance(db, input.PayerWallet)
+			if quote.StakedBalance.IsPositive() {
+				discount, err := s.findRule(db, input.FeeType, FeeRuleStaked, quote.StakedBalance)
+				if err != nil {
+					return nil, err
+				}
+				if discount != nil {
+					quote.Discount = discount.Discount
+					quote.Rate = quote.BaseRate.Mul(decimal.NewFromInt(1).Sub(discount.Discount))
+				}
+			}
+		}
+	}
+
+	fee := input.Amount.Mul(quote.Rate)
+	if quote.MinFee.IsPositive() && fee.LessThan(quote.MinFee) {
+		fee = quote.MinFee
+	}
+	if quote.MaxFee.IsPositive() && fee.GreaterThan(quote.MaxFee) {
+		fee = quote.MaxFee
+	}
+	quote.Fee = fee.Round(18)
+	if quote.Fee.GreaterThanOrEqual(input.Amount) {
+		return nil, fmt.Errorf("amount must be greater than the fee (%s)", quote.Fee.String())
+	}
+	quote.NetAmount = input.Amount.Sub(quote.Fee)
+	return quote, nil
+}
+
+// defaultRate 未配置分档时的默认费率
+func (s *FeeService) defaultRate(feeType string) decimal.Decimal {
+	if feeType == FeeTypeWithdraw {
+		return decimal.NewFromFloat(s.cfg.WithdrawFeeRate)
+	}
+	return decimal.NewFromFloat(s.cfg.TipFeeRate)
+}
+
+// activeRules 当前生效的规则
+func activeRules(db *gorm.DB, feeType string, kind string) *gorm.DB {
+	now := time.Now()
+	return db.Model(&models.FeeRule{}).
+		Where("fee_type = ? AND kind = ? AND is_active = ?", feeType, kind, true).
+		Where("start_at IS NULL OR start_at <= ?", now).
+		Where("end_at IS NULL OR end_at > ?", now)
+}
+
+// findAgentRule 查找 Agent 专属费率
+func (s *FeeService) findAgentRule(db *gorm.DB, feeType string, agentID uint) (*models.FeeRule, error) {
+	if agentID == 0 {
+		return nil, nil
+	}
+	var rule models.FeeRule
+	err := activeRules(db, feeType, FeeRuleAgent).Where("agent_id = ?", agentID).
+		Order("rate asc").First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// findRule 查找门槛不超过 value 的最高档（分档/质押折扣）
+func (s *FeeService) findRule(db *gorm.DB, feeType string, kind string, value decimal.Decimal) (*models.FeeRule, error) {
+	var rule models.FeeRule
+	err := activeRules(db, feeType, kind).Where("threshold <= ?", value).
+		Order("threshold desc").First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// stakedBalance 用户的质押量
+func (s *FeeService) stakedBalance(db *gorm.DB, wallet string) decimal.Decimal {
+	var balance models.TokenBalance
+	if err := db.Where("wallet_address = ?", wallet).First(&balance).Error; err != nil {
+		return decimal.Zero
+	}
+	return balance.StakedBalance
+}
+
+// recentVolume 付款方近期的同类交易量
+func (s *FeeService) recentVolume(db *gorm.DB, input FeeInput) decimal.Decimal {
+	since := time.Now().AddDate(0, 0, -s.cfg.FeeVolumeDays)
+
+	var volume decimal.Decimal
+	switch input.FeeType {
+	case FeeTypeTip:
+		query := db.Model(&models.TokenTip{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("created_at >= ?", since)
+		if input.PayerType == "agent" {
+			query = query.Where("from_type = ? AND from_agent_id = ?", "agent", input.PayerID)
+		} else {
+			query = query.Where("from_wallet = ?", input.PayerWallet)
+		}
+		query.Scan(&volume)
+	case FeeTypeWithdraw:
+		query := db.Model(&models.Withdrawal{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("status <> ? AND created_at >= ?", "failed", since)
+		if input.PayerType == "agent" {
+			query = query.Where("user_type = ? AND user_id = ?", "agent", input.PayerID)
+		} else {
+			query = query.Where("user_type = ? AND wallet_address = ?", "user", input.PayerWallet)
+		}
+		query.Scan(&volume)
+	}
+	return volume
+}
+
+// ==================== 规则管理（管理员）====================
+
+// FeeRuleInput 创建/修改规则参数（修改时 nil 表示不修改）
+type FeeRuleInput struct {
+	FeeType   *string
+	Kind      *string
+	AgentID   *uint
+	Threshold *decimal.Decimal
+	Rate      *decimal.Decimal
+	Discount  *decimal.Decimal
+	MinFee    *decimal.Decimal
+	MaxFee    *decimal.Decimal
+	StartAt   *time.Time
+	EndAt     *time.Time
+	Note      *string
+	IsActive  *bool
+}
+
+// ListRules 获取规则（feeType 为空时返回全部）
+func (s *FeeService) ListRules(feeType string) ([]models.FeeRule, error) {
+	var rules []models.FeeRule
+	query := s.db.Model(&models.FeeRule{})
+	if feeType != "" {
+		query = query.Where("fee_type = ?", feeType)
+	}
+	err := query.Order("fee_type asc, kind asc, threshold asc, id asc").Find(&rules).Error
+	return rules, err
+}
+
+// CreateRule 创建规则
+func (s *FeeService) CreateRule(input FeeRuleInput, actor AdminActor) (*models.FeeRule, error) {
+	if input.FeeType == nil || input.Kind == nil {
+		return nil, errors.New("feeType and kind are required")
+	}
+
+	rule := models.FeeRule{IsActive: true}
+	if err := applyFeeRuleInput(&rule, input); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rule).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, "fee_rule.create", "fee_rule", fmt.Sprint(rule.ID), nil, rule)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateRule 修改规则
+func (s *FeeService) UpdateRule(id uint, input FeeRuleInput, actor AdminActor) (*models.FeeRule, error) {
+	var rule models.FeeRule
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&rule, id).Error; err != nil {
+			return errors.New("fee rule not found")
+		}
+		before := rule
+
+		if err := applyFeeRuleInput(&rule, input); err != nil {
+			return err
+		}
+		if err := tx.Save(&rule).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, "fee_rule.update", "fee_rule", fmt.Sprint(rule.ID), before, rule)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// applyFeeRuleInput 校验并应用规则参数
+func applyFeeRuleInput(rule *models.FeeRule, input FeeRuleInput) error {
+	if input.FeeType != nil {
+		rule.FeeType = *input.FeeType
+	}
+	if input.Kind != nil {
+		rule.Kind = *input.Kind
+	}
+	if input.AgentID != nil {
+		rule.AgentID = input.AgentID
+	}
+	if input.Threshold != nil {
+		rule.Threshold = *input.Threshold
+	}
+	if input.Rate != nil {
+		rule.Rate = *input.Rate
+	}
+	if input.Discount != nil {
+		rule.Discount = *input.Discount
+	}
+	if input.MinFee != nil {
+		rule.MinFee = *input.MinFee
+	}
+	if input.MaxFee != nil {
+		rule.MaxFee = *input.MaxFee
+	}
+	if input.StartAt != nil {
+		rule.StartAt = input.StartAt
+	}
+	if input.EndAt != nil {
+		rule.EndAt = input.EndAt
+	}
+	if input.Note != nil {
+		rule.Note = *input.Note
+	}
+	if input.IsActive != nil {
+		rule.IsActive = *input.IsActive
+	}
+
+	one := decimal.NewFromInt(1)
+	if rule.FeeType != FeeTypeTip && rule.FeeType != FeeTypeWithdraw {
+		return errors.New("feeType must be tip or withdraw")
+	}
+	switch rule.Kind {
+	case FeeRuleAgent:
+		if rule.AgentID == nil || *rule.AgentID == 0 {
+			return errors.New("agentId is required for agent rules")
+		}
+	case FeeRuleTier, FeeRuleStaked:
+		rule.AgentID = nil
+	default:
+		return errors.New("kind must be tier, agent or staked")
+	}
+	if rule.Threshold.IsNegative() || rule.MinFee.IsNegative() || rule.MaxFee.IsNegative() {
+		return errors.New("threshold, minFee and maxFee must not be negative")
+	}
+	if rule.Rate.IsNegative() || rule.Rate.GreaterThanOrEqual(one) {
+		return errors.New("rate must be between 0 and 1")
+	}
+	if rule.Discount.IsNegative() || rule.Discount.GreaterThan(one) {
+		return errors.New("discount must be between 0 and 1")
+	}
+	if rule.MaxFee.IsPositive() && rule.MaxFee.LessThan(rule.MinFee) {
+		return errors.New("maxFee must not be less than minFee")
+	}
+	if rule.StartAt != nil && rule.EndAt != nil && !rule.EndAt.After(*rule.StartAt) {
+		return errors.New("endAt must be after startAt")
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// "为你推荐"信息流：
+//   - 兴趣画像：最近 FeedHistoryDays 天的点赞(1)、评论(3)、打赏(10) 按帖子的分类/话题/Agent 累加，关注的 Agent 额外加权，各维度归一化到 0~1
+//   - 候选集：近 7 天热门 + 感兴趣 Agent 近 30 天的帖子，不足时补充更早的热门；排除已互动和 FeedSeenHours 内已推荐过的帖子
+//   - 打分：由 FeedScorer 计算（FEED_SCORER 选择实现，可通过 RegisterFeedScorer 扩展）
+//   - 探索：每页约 FeedExploreRate 的位置从排名靠后的候选中随机挑选，避免兴趣越推越窄
+//   - 返回的帖子记为已推荐，再次请求即得到下一批
+const (
+	feedCandidateHot     = 300
+	feedCandidateAgent   = 200
+	feedProfileTopAgents = 20
+	feedFollowWeight     = 5.0
+)
+
+// InterestProfile 用户兴趣画像（各维度 0~1）
+type InterestProfile struct {
+	Categories   map[string]float64
+	Topics       map[string]float64
+	Agents       map[uint]float64
+	Interacted   map[uint]bool // 已互动过的帖子
+	Interactions int
+}
+
+// FeedScorer 推荐打分器
+type FeedScorer interface {
+	Score(profile *InterestProfile, post *models.Post, now time.Time) float64
+}
+
+// AffinityScorer 默认打分：兴趣匹配 + 热度 + 新鲜度
+type AffinityScorer struct {
+	Category  float64
+	Topic     float64
+	Agent     float64
+	Hotness   float64
+	Freshness float64
+}
+
+// Score 计算帖子得分
+func (s AffinityScorer) Score(profile *InterestProfile, post *models.Post, now time.Time) float64 {
+	topic := 0.0
+	for _, t := range strings.Split(post.Topics, ",") {
+		if v := profile.Topics[strings.TrimSpace(t)]; v > topic {
+			topic = v
+		}
+	}
+	hours := now.Sub(post.PostedAt).Hours()
+	if hours < 0 {
+		hours = 0
+	}
+	return s.Category*profile.Categories[post.Category] +
+		s.Topic*topic +
+		s.Agent*profile.Agents[post.AgentID] +
+		s.Hotness*math.Log1p(math.Max(post.HotnessScore, 0)) +
+		s.Freshness/(1+hours/24)
+}
+
+var feedScorers = map[string]FeedScorer{
+	"affinity": AffinityScorer{Category: 1, Topic: 1.5, Agent: 2, Hotness: 0.5, Freshness: 1},
+}
+
+// RegisterFeedScorer 注册推荐打分器（在启动时调用）
+func RegisterFeedScorer(name string, scorer FeedScorer) {
+	feedScorers[name] = scorer
+}
+
+type FeedService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewFeedService(db *gorm.DB, cfg *config.Config) *FeedService {
+	return &FeedService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// scorer 当前使用的打分器（未知名称时使用默认）
+func (s *FeedService) scorer() FeedScorer {
+	if scorer, ok := feedScorers[s.cfg.FeedScorer]; ok {
+		return scorer
+	}
+	return feedScorers["affinity"]
+}
+
+// BuildProfile 根据互动历史生成兴趣画像
+func (s *FeedService) BuildProfile(user *models.User) (*InterestProfile, error) {
+	since := time.Now().AddDate(0, 0, -s.cfg.FeedHistoryDays)
+	wallet := strings.ToLower(user.WalletAddress)
+
+	type interaction struct {
+		PostID   uint
+		Category string
+		Topics   string
+		AgentID  uint
+		Weight   float64
+	}
+	var rows []interaction
+	err := s.db.Raw(`SELECT p.id AS post_id, p.category, p.topics, p.agent_id, x.weight FROM (
+			SELECT post_id, 1.0 AS weight FROM likes WHERE deleted_at IS NULL AND LOWER(wallet_address) = ? AND created_at >= ?
+			UNION ALL
+			SELECT post_id, 3.0 FROM comments WHERE deleted_at IS NULL AND (user_id = ? OR LOWER(wallet_address) = ?) AND created_at >= ?
+			UNION ALL
+			SELECT post_id, 10.0 FROM token_tips WHERE deleted_at IS NULL AND from_wallet = ? AND created_at >= ?
+			UNION ALL
+			SELECT post_id, 10.0 FROM tip_records WHERE deleted_at IS NULL AND LOWER(user_wallet) = ? AND created_at >= ?
+		) x JOIN posts p ON p.id = x.post_id AND p.deleted_at IS NULL`,
+		wallet, since, user.ID, wallet, since, wallet, since, wallet, since).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &InterestProfile{
+		Categories:   make(map[string]float64),
+		Topics:       make(map[string]float64),
+		Agents:       make(map[uint]float64),
+		Interacted:   make(map[uint]bool),
+		Interactions: len(rows),
+	}
+	for _, r := range rows {
+		profile.Interacted[r.PostID] = true
+		profile.Categories[r.Category] += r.Weight
+		profile.Agents[r.AgentID] += r.Weight
+		for _, t := range strings.Split(r.Topics, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				profile.Topics[t] += r.Weight
+			}
+		}
+	}
+
+	var followed []uint
+	s.db.Model(&models.Follow{}).Where("user_id = ?", user.ID).Pluck("agent_id", &followed)
+	for _, id := range followed {
+		profile.Agents[id] += feedFollowWeight
+	}
+
+	normalize(profile.Categories)
+	normalize(profile.Topics)
+	normalize(profile.Agents)
+	return profile, nil
+}
+
+// ForYou 生成一页推荐并记为已推荐
+func (s *FeedService) ForYou(user *models.User, limit int) ([]models.Post, error) {
+	profile, err := s.BuildProfile(user)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.candidates(user, profile, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	scorer := s.scorer()
+	scores := make(map[uint]float64, len(candidates))
+	for i := range candidates {
+		scores[candidates[i].ID] = scorer.Score(profile, &candidates[i], now)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i].ID] > scores[candidates[j].ID]
+	})
+
+	picked := pickWithExploration(candidates, limit, s.cfg.FeedExploreRate)
+	if len(picked) == 0 {
+		return []models.Post{}, nil
+	}
+
+	ids := make([]uint, len(picked))
+	for i, p := range picked {
+		ids[i] = p.ID
+	}
+	var found []models.Post
+	if err := s.db.Preload("Agent").Preload("Images").Preload("Videos").
+		Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Post, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	posts := make([]models.Post, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			posts = append(posts, p)
+		}
+	}
+
+	s.markSeen(user.ID, ids)
+	return posts, nil
+}
+
+// candidates 候选帖子（不含已互动和近期已推荐的）
+func (s *FeedService) candidates(user *models.User, profile *InterestProfile, limit int) ([]models.Post, error) {
+	seenSince := time.Now().Add(-time.Duration(s.cfg.FeedSeenHours) * time.Hour)
+	seen := s.db.Model(&models.FeedImpression{}).Select("post_id").
+		Where("user_id = ? AND seen_at >= ?", user.ID, seenSince)
+
+	byID := make(map[uint]models.Post)
+	add := func(posts []models.Post) {
+		for _, p := range posts {
+			if !profile.Interacted[p.ID] {
+				byID[p.ID] = p
+			}
+		}
+	}
+
+	var hot []models.Post
+	if err := s.db.Where("posted_at >= ? AND id NOT IN (?)", time.Now().AddDate(0, 0, -7), seen).
+		Order("hotness_score DESC").Limit(feedCandidateHot).Find(&hot).Error; err != nil {
+		return nil, err
+	}
+	add(hot)
+
+	if agentIDs := topAgents(profile.Agents, feedProfileTopAgents); len(agentIDs) > 0 {
+		var fromAgents []models.Post
+		if err := s.db.Where("agent_id IN ? AND posted_at >= ? AND id NOT IN (?)", agentIDs, time.Now().AddDate(0, 0, -30), seen).
+			Order("posted_at DESC").Limit(feedCandidateAgent).Find(&fromAgents).Error; err != nil {
+			return nil, err
+		}
+		add(fromAgents)
+	}
+
+	// 近期内容不够时补充更早的热门
+	if len(byID) < limit*2 {
+		var older []models.Post
+		if err := s.db.Where("id NOT IN (?)", seen).
+			Order("hotness_score DESC, posted_at DESC").Limit(feedCandidateHot).Find(&older).Error; err != nil {
+			return nil, err
+		}
+		add(older)
+	}
+
+	posts := make([]models.Post, 0, len(byID))
+	for _, p := range byID {
+		posts = append(posts, p)
+	}
+	return posts, nil
+}
+
+// markSeen 记录已推荐的帖子并清理过期记录
+func (s *FeedService) markSeen(userID uint, postIDs []uint) {
+	now := time.Now()
+	impressions := make([]models.FeedImpression, len(postIDs))
+	for i, id := range postIDs {
+		impressions[i] = models.FeedImpression{UserID: userID, PostID: id, SeenAt: now}
+	}
+	s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"seen_at": now}),
+	}).Create(&impressions)
+
+	s.db.Where("user_id = ? AND seen_at < ?", userID, now.Add(-time.Duration(s.cfg.FeedSeenHours)*time.Hour)).
+		Delete(&models.FeedImpression{})
+}
+
+// pickWithExploration 从排好序的候选中取一页，约 rate 比例的位置随机换成排名靠后的候选
+func pickWithExploration(ranked []models.Post, limit int, rate float64) []models.Post {
+	if len(ranked) <= limit {
+		return ranked
+	}
+	explore := int(math.Round(float64(limit) * rate))
+	if explore <= 0 {
+		return ranked[:limit]
+	}
+	if explore > limit {
+		explore = limit
+	}
+
+	exploit := ranked[:limit-explore]
+	pool := append([]models.Post(nil), ranked[limit-explore:]...)
+	rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	explored := pool[:explore]
+
+	// 探索位均匀穿插在结果中
+	step := limit / explore
+	result := make([]models.Post, 0, limit)
+	for len(result) < limit {
+		if (len(result)+1)%step == 0 && len(explored) > 0 {
+			result = append(result, explored[0])
+			explored = explored[1:]
+			continue
+		}
+		if len(exploit) > 0 {
+			result = append(result, exploit[0])
+			exploit = exploit[1:]
+			continue
+		}
+		result = append(result, explored[0])
+		explored = explored[1:]
+	}
+	return result
+}
+
+// topAgents 兴趣最高的若干个 Agent
+func topAgents(agents map[uint]float64, n int) []uint {
+	ids := make([]uint, 0, len(agents))
+	for id := range agents {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return agents[ids[i]] > agents[ids[j]] })
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+// normalize 各维度除以最大值，归一化到 0~1
+func normalize[K comparable](m map[K]float64) {
+	maxValue := 0.0
+	for _, v := range m {
+		maxValue = math.Max(maxValue, v)
+	}
+	if maxValue == 0 {
+		return
+	}
+	for k, v := range m {
+		m[k] = v / maxValue
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 热度分数（类似 Hacker News / Reddit 热度算法）：
+//   - score = (点赞*点赞权重 + 评论*评论权重 + 打赏次数*打赏权重 + 打赏总额权重*ln(1+代币打赏总额)) / (小时数+2)^gravity
+//   - 点赞门槛最低，评论需要花时间，打赏需要花费积分/代币，权重依次升高
+//   - 代币打赏总额取对数，避免单笔大额打赏压过所有互动
+//   - (小时数 + 2) 避免新帖子分母过小；gravity 越大衰减越快
+//   - 互动发生时立即重算该帖子；后台任务定期重算窗口期内及仍有热度的帖子，让老帖子自然衰减
+//   - 分数直接用 SQL 表达式在数据库内计算，sort=hot 走 (hotness_score, posted_at, id) 索引
+//
+// 其他排序分数与热度一起维护，各自有 (分数, posted_at, id) 索引：
+//   - engagement_score（sort=top）：上式的分子，不随时间衰减
+//   - rising_score（sort=rising）：最近 RisingHours 小时每小时的加权互动，取自按小时汇总的 post_activities，
+//     只统计发布不超过 RisingMaxAgeHours 的帖子
+//   - controversy_score（sort=controversial）：评论占互动的比例 * ln(1+评论数)，评论多而点赞少的帖子靠前
+const (
+	hotnessBatchSize      = 1000
+	hotnessFloor          = 0.001 // 窗口期外的帖子热度低于此值后不再重算
+	postActivityRetention = 7 * 24 * time.Hour
+)
+
+// ActivityDelta 一次互动对帖子小时汇总的增量（取消点赞为负数）
+type ActivityDelta struct {
+	Likes      int
+	Comments   int
+	Tips       int
+	TipsAmount decimal.Decimal
+}
+
+type HotnessService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewHotnessService(db *gorm.DB, cfg *config.Config) *HotnessService {
+	return &HotnessService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// engagementExpr 加权互动 SQL 表达式及参数（不含时间衰减）
+func (s *HotnessService) engagementExpr() (string, []interface{}) {
+	return `(likes_count * CAST(? AS float8) + comments_count * CAST(? AS float8) + tips_count * CAST(? AS float8)
+		+ CAST(? AS float8) * CAST(LN(1 + GREATEST(tips_amount, 0)) AS float8))`,
+		[]interface{}{s.cfg.HotnessLikeWeight, s.cfg.HotnessCommentWeight, s.cfg.HotnessTipWeight, s.cfg.HotnessTipAmountWeight}
+}
+
+// expr 热度 SQL 表达式及参数
+func (s *HotnessService) expr() (string, []interface{}) {
+	sql, args := s.engagementExpr()
+	return sql + `
+		/ POWER(GREATEST(CAST(EXTRACT(EPOCH FROM (NOW() - posted_at)) AS float8) / 3600, 0) + 2, CAST(? AS float8))`,
+		append(args, s.cfg.HotnessGravity)
+}
+
+// controversyExpr 争议度 SQL 表达式
+func controversyExpr() string {
+	return `CAST(comments_count AS float8) / (comments_count + GREATEST(likes_count, 0) + 1)
+		* CAST(LN(1 + GREATEST(comments_count, 0)) AS float8)`
+}
+
+// risingExpr 上升速度 SQL 表达式及参数（按 post_activities 最近 RisingHours 小时的汇总）
+func (s *HotnessService) risingExpr() (string, []interface{}) {
+	now := time.Now()
+	return `CASE WHEN posted_at >= ? THEN GREATEST(COALESCE((
+			SELECT SUM(a.likes * CAST(? AS float8) + a.comments * CAST(? AS float8) + a.tips * CAST(? AS float8)
+				+ CAST(? AS float8) * CAST(LN(1 + GREATEST(a.tips_amount, 0)) AS float8))
+			FROM post_activities a WHERE a.post_id = posts.id AND a.hour >= ?
+		), 0), 0) / CAST(? AS float8) ELSE 0 END`,
+		[]interface{}{
+			now.Add(-time.Duration(s.cfg.RisingMaxAgeHours) * time.Hour),
+			s.cfg.HotnessLikeWeight, s.cfg.HotnessCommentWeight, s.cfg.HotnessTipWeight, s.cfg.HotnessTipAmountWeight,
+			s.risingSince(now),
+			s.cfg.RisingHours,
+		}
+}
+
+// risingSince rising 统计的起始小时（包含当前未满的一小时）
+func (s *HotnessService) risingSince(now time.Time) time.Time {
+	return now.Truncate(time.Hour).Add(-time.Duration(s.cfg.RisingHours-1) * time.Hour)
+}
+
+// scoreColumns 热度、总互动分和争议度的更新内容
+func (s *HotnessService) scoreColumns() map[string]interface{} {
+	hotness, hotnessArgs := s.expr()
+	engagement, engagementArgs := s.engagementExpr()
+	return map[string]interface{}{
+		"hotness_score":     gorm.Expr(hotness, hotnessArgs...),
+		"engagement_score":  gorm.Expr(engagement, engagementArgs...),
+		"controversy_score": gorm.Expr(controversyExpr()),
+	}
+}
+
+// UpdatePost 立即重算单个帖子的各项排序分数（不更新 updated_at）
+func (s *HotnessService) UpdatePost(postID uint) error {
+	columns := s.scoreColumns()
+	rising, args := s.risingExpr()
+	columns["rising_score"] = gorm.Expr(rising, args...)
+	return s.db.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(columns).Error
+}
+
+// RecordActivity 累加帖子当前小时的互动汇总
+func (s *HotnessService) RecordActivity(postID uint, delta ActivityDelta) error {
+	activity := models.PostActivity{
+		PostID:     postID,
+		Hour:       time.Now().Truncate(time.Hour),
+		Likes:      delta.Likes,
+		Comments:   delta.Comments,
+		Tips:       delta.Tips,
+		TipsAmount: delta.TipsAmount,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "post_id"}, {Name: "hour"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"likes":       gorm.Expr("post_activities.likes + ?", delta.Likes),
+			"comments":    gorm.Expr("post_activities.comments + ?", delta.Comments),
+			"tips":        gorm.Expr("post_activities.tips + ?", delta.Tips),
+			"tips_amount": gorm.Expr("post_activities.tips_amount + ?", delta.TipsAmount),
+		}),
+	}).Create(&activity).Error
+}
+
+// StartHotnessUpdater 启动热度重算任务
+func (s *HotnessService) StartHotnessUpdater(ctx context.Context) {
+	if err := s.backfillTipsAmount(); err != nil {
+		log.Printf("Hotness updater: failed to backfill tips amount: %v", err)
+	}
+	if err := s.backfillScores(); err != nil {
+		log.Printf("Hotness updater: failed to backfill scores: %v", err)
+	}
+	s.Recompute()
+
+	ticker := time.NewTicker(time.Duration(s.cfg.HotnessIntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Hotness updater stopped")
+			return
+		case <-ticker.C:
+			s.Recompute()
+		}
+	}
+}
+
+// Recompute 按批重算窗口期内的帖子，以及窗口期外仍有热度的帖子；随后重算 rising 并清理过期的小时汇总
+func (s *HotnessService) Recompute() {
+	s.recomputeHotness()
+	s.recomputeRising()
+}
+
+// recomputeHotness 按 ID 分批重算热度
+func (s *HotnessService) recomputeHotness() {
+	since := time.Now().AddDate(0, 0, -s.cfg.HotnessWindowDays)
+	columns := s.scoreColumns()
+
+	var lastID uint
+	updated := 0
+	for {
+		var ids []uint
+		if err := s.db.Model(&models.Post{}).
+			Where("(posted_at >= ? OR hotness_score > ?) AND id > ?", since, hotnessFloor, lastID).
+			Order("id").Limit(hotnessBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			log.Printf("Hotness updater: failed to load posts: %v", err)
+			return
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := s.db.Model(&models.Post{}).Where("id IN ?", ids).
+			UpdateColumns(columns).Error; err != nil {
+			log.Printf("Hotness updater: failed to update posts: %v", err)
+			return
+		}
+		updated += len(ids)
+		lastID = ids[len(ids)-1]
+		if len(ids) < hotnessBatchSize {
+			break
+		}
+	}
+	if updated > 0 {
+		log.Printf("Hotness updater: recomputed %d posts", updated)
+	}
+}
+
+// recomputeRising 重算有近期互动或仍在 rising 榜上的帖子
+func (s *HotnessService) recomputeRising() {
+	now := time.Now()
+	rising, args := s.risingExpr()
+	active := s.db.Model(&models.PostActivity{}).Select("post_id").Where("hour >= ?", s.risingSince(now))
+	if err := s.db.Model(&models.Post{}).
+		Where("rising_score > 0 OR id IN (?)", active).
+		UpdateColumn("rising_score", gorm.Expr(rising, args...)).Error; err != nil {
+		log.Printf("Hotness updater: failed to update rising scores: %v", err)
+		return
+	}
+
+	if err := s.db.Where("hour < ?", now.Add(-postActivityRetention)).
+		Delete(&models.PostActivity{}).Error; err != nil {
+		log.Printf("Hotness updater: failed to prune post activity: %v", err)
+	}
+}
+
+// backfillScores 补齐引入总互动分和争议度之前的帖子（窗口期外的帖子不会被定期重算）
+func (s *HotnessService) backfillScores() error {
+	engagement, args := s.engagementExpr()
+	return s.db.Model(&models.Post{}).
+		Where("engagement_score = 0 AND (likes_count > 0 OR comments_count > 0 OR tips_count > 0)").
+		UpdateColumns(map[string]interface{}{
+			"engagement_score":  gorm.Expr(engagement, args...),
+			"controversy_score": gorm.Expr(controversyExpr()),
+		}).Error
+}
+
+// backfillTipsAmount 补齐引入 tips_amount 之前的代币打赏总额
+func (s *HotnessService) backfillTipsAmount() error {
+	return s.db.Exec(`UPDATE posts SET tips_amount = t.total
+		FROM (SELECT post_id, SUM(amount) AS total FROM token_tips WHERE deleted_at IS NULL GROUP BY post_id) t
+		WHERE posts.id = t.post_id AND posts.tips_amount = 0`).Error
+}
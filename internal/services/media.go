@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// 上传文件的类型与大小限制（上传接口和引用媒体的评论共用）
+const (
+	MaxImageUploadSize = 5 * 1024 * 1024
+	MaxVideoUploadSize = 10 * 1024 * 1024
+)
+
+var uploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+}
+
+// UploadExtension 允许上传的类型对应的扩展名
+func UploadExtension(contentType string) (string, bool) {
+	ext, ok := uploadExtensions[contentType]
+	return ext, ok
+}
+
+// IsVideoType 是否为视频类型
+func IsVideoType(contentType string) bool {
+	return strings.HasPrefix(contentType, "video/")
+}
+
+// MaxUploadSize 该类型允许的最大文件大小
+func MaxUploadSize(contentType string) int64 {
+	if IsVideoType(contentType) {
+		return MaxVideoUploadSize
+	}
+	return MaxImageUploadSize
+}
+
+// validateUploads 校验 URL 均来自上传接口，且类型和大小符合上传规则
+func validateUploads(db *gorm.DB, urls []string, video bool) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	var uploads []models.Upload
+	if err := db.Where("url IN ?", urls).Find(&uploads).Error; err != nil {
+		return err
+	}
+	byURL := make(map[string]models.Upload, len(uploads))
+	for _, u := range uploads {
+		byURL[u.URL] = u
+	}
+	for _, url := range urls {
+		u, ok := byURL[url]
+		if !ok {
+			return errors.New("media must be uploaded through /upload first")
+		}
+		if _, allowed := UploadExtension(u.ContentType); !allowed || IsVideoType(u.ContentType) != video {
+			return errors.New("unsupported media type")
+		}
+		if u.Size > MaxUploadSize(u.ContentType) {
+			return errors.New("media file is too large")
+		}
+	}
+	return nil
+}
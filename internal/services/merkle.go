@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MerkleDistributor 兼容的 Merkle 树：
+//   - 叶子 = keccak256(abi.encodePacked(uint256 index, address account, uint256 amount))
+//   - 父节点 = keccak256(较小哈希 ++ 较大哈希)（排序拼接，与 OpenZeppelin MerkleProof 一致）
+//   - 奇数层最后一个节点直接上提，不生成证明元素
+type MerkleTree struct {
+	layers [][]common.Hash
+}
+
+// DistributorLeaf 计算分发合约叶子哈希
+func DistributorLeaf(index uint, account common.Address, amount *big.Int) common.Hash {
+	return crypto.Keccak256Hash(
+		math.U256Bytes(new(big.Int).SetUint64(uint64(index))),
+		account.Bytes(),
+		math.U256Bytes(new(big.Int).Set(amount)),
+	)
+}
+
+// NewMerkleTree 由叶子构建 Merkle 树（叶子顺序即 index 顺序）
+func NewMerkleTree(leaves []common.Hash) *MerkleTree {
+	tree := &MerkleTree{}
+	if len(leaves) == 0 {
+		return tree
+	}
+
+	layer := append([]common.Hash(nil), leaves...)
+	tree.layers = append(tree.layers, layer)
+	for len(layer) > 1 {
+		next := make([]common.Hash, 0, (len(layer)+1)/2)
+		for i := 0; i < len(layer); i += 2 {
+			if i+1 == len(layer) {
+				next = append(next, layer[i])
+				continue
+			}
+			next = append(next, hashPair(layer[i], layer[i+1]))
+		}
+		tree.layers = append(tree.layers, next)
+		layer = next
+	}
+	return tree
+}
+
+// Root Merkle 根
+func (t *MerkleTree) Root() common.Hash {
+	if len(t.layers) == 0 {
+		return common.Hash{}
+	}
+	return t.layers[len(t.layers)-1][0]
+}
+
+// Proof 获取第 index 个叶子的证明
+func (t *MerkleTree) Proof(index int) []common.Hash {
+	proof := []common.Hash{}
+	for _, layer := range t.layers[:max(len(t.layers)-1, 0)] {
+		sibling := index ^ 1
+		if sibling < len(layer) {
+			proof = append(proof, layer[sibling])
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleProof 校验证明（与合约端逻辑一致）
+func VerifyMerkleProof(leaf common.Hash, proof []common.Hash, root common.Hash) bool {
+	computed := leaf
+	for _, p := range proof {
+		computed = hashPair(computed, p)
+	}
+	return computed == root
+}
+
+// hashPair 排序后拼接哈希
+func hashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a.Bytes(), b.Bytes()) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a.Bytes(), b.Bytes())
+}
+
+// ==================== Merkle-sum 树（储备金证明）====================
+
+// Merkle-sum 树：每个节点带有子树余额之和，根节点的和即总负债
+//   - 叶子哈希 = keccak256(账户标识 ++ 随机盐 ++ uint256(余额))
+//   - 父节点哈希 = keccak256(左哈希 ++ uint256(左和) ++ 右哈希 ++ uint256(右和))，和 = 左和 + 右和
+//   - 奇数层最后一个节点直接上提
+type MerkleSumNode struct {
+	Hash common.Hash
+	Sum  *big.Int
+}
+
+type MerkleSumTree struct {
+	layers [][]MerkleSumNode
+}
+
+// MerkleSumProofItem 证明中的兄弟节点（Position 表示兄弟节点在左还是右）
+type MerkleSumProofItem struct {
+	Hash     string `json:"hash"`
+	Sum      string `json:"sum"`
+	Position string `json:"position"` // left/right
+}
+
+// MerkleSumLeaf 计算储备金证明叶子
+func MerkleSumLeaf(accountKey string, salt []byte, balance *big.Int) MerkleSumNode {
+	return MerkleSumNode{
+		Hash: crypto.Keccak256Hash([]byte(accountKey), salt, math.U256Bytes(new(big.Int).Set(balance))),
+		Sum:  new(big.Int).Set(balance),
+	}
+}
+
+// NewMerkleSumTree 由叶子构建 Merkle-sum 树
+func NewMerkleSumTree(leaves []MerkleSumNode) *MerkleSumTree {
+	tree := &MerkleSumTree{}
+	if len(leaves) == 0 {
+		return tree
+	}
+
+	layer := append([]MerkleSumNode(nil), leaves...)
+	tree.layers = append(tree.layers, layer)
+	for len(layer) > 1 {
+		next := make([]MerkleSumNode, 0, (len(layer)+1)/2)
+		for i := 0; i < len(layer); i += 2 {
+			if i+1 == len(layer) {
+				next = append(next, layer[i])
+				continue
+			}
+			next = append(next, hashSumPair(layer[i], layer[i+1]))
+		}
+		tree.layers = append(tree.layers, next)
+		layer = next
+	}
+	return tree
+}
+
+// Root 根节点（和为总负债）
+func (t *MerkleSumTree) Root() MerkleSumNode {
+	if len(t.layers) == 0 {
+		return MerkleSumNode{Sum: new(big.Int)}
+	}
+	return t.layers[len(t.layers)-1][0]
+}
+
+// Proof 获取第 index 个叶子的证明
+func (t *MerkleSumTree) Proof(index int) []MerkleSumProofItem {
+	proof := []MerkleSumProofItem{}
+	for _, layer := range t.layers[:max(len(t.layers)-1, 0)] {
+		sibling := index ^ 1
+		if sibling < len(layer) {
+			position := "right"
+			if sibling < index {
+				position = "left"
+			}
+			proof = append(proof, MerkleSumProofItem{
+				Hash:     layer[sibling].Hash.Hex(),
+				Sum:      layer[sibling].Sum.String(),
+				Position: position,
+			})
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleSumProof 校验证明，返回是否与根一致
+func VerifyMerkleSumProof(leaf MerkleSumNode, proof []MerkleSumProofItem, root MerkleSumNode) bool {
+	computed := leaf
+	for _, p := range proof {
+		sum, ok := new(big.Int).SetString(p.Sum, 10)
+		if !ok || sum.Sign() < 0 {
+			return false
+		}
+		sibling := MerkleSumNode{Hash: common.HexToHash(p.Hash), Sum: sum}
+		if p.Position == "left" {
+			computed = hashSumPair(sibling, computed)
+		} else {
+			computed = hashSumPair(computed, sibling)
+		}
+	}
+	return computed.Hash == root.Hash && computed.Sum.Cmp(root.Sum) == 0
+}
+
+// hashSumPair 合并左右节点
+func hashSumPair(left, right MerkleSumNode) MerkleSumNode {
+	return MerkleSumNode{
+		Hash: crypto.Keccak256Hash(
+			left.Hash.Bytes(), math.U256Bytes(new(big.Int).Set(left.Sum)),
+			right.Hash.Bytes(), math.U256Bytes(new(big.Int).Set(right.Sum)),
+		),
+		Sum: new(big.Int).Add(left.Sum, right.Sum),
+	}
+}
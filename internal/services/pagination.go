@@ -0,0 +1,64 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 游标分页（keyset）：
+//   - 游标是上一页最后一条记录的排序键（分数/时间 + ID）的 base64 编码，对客户端不透明
+//   - 下一页条件为 (排序键, id) < (游标值)，新增记录不会导致重复或跳过
+//   - 原有的 page/offset 参数继续可用，未传 cursor 时走原逻辑
+type Cursor struct {
+	Score *float64   `json:"s,omitempty"`
+	Time  *time.Time `json:"t,omitempty"`
+	ID    uint       `json:"id"`
+}
+
+// EncodeCursor 编码游标
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解码游标
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 || cursor.Time == nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// TimeCursor 按时间排序的游标
+func TimeCursor(t time.Time, id uint) string {
+	return EncodeCursor(Cursor{Time: &t, ID: id})
+}
+
+// KeysetByTime 按 column DESC, id DESC 排序并从游标之后开始（cursor 为 nil 时只排序）
+func KeysetByTime(table string, column string, cursor *Cursor) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if cursor != nil {
+			db = db.Where("("+table+"."+column+", "+table+".id) < (?, ?)", *cursor.Time, cursor.ID)
+		}
+		return db.Order(table + "." + column + " DESC").Order(table + ".id DESC")
+	}
+}
+
+// KeysetSinceTime 按 column ASC, id ASC 排序并从游标之后开始，用于按时间顺序拉取新增记录
+func KeysetSinceTime(table string, column string, cursor *Cursor) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if cursor != nil {
+			db = db.Where("("+table+"."+column+", "+table+".id) > (?, ?)", *cursor.Time, cursor.ID)
+		}
+		return db.Order(table + "." + column + " ASC").Order(table + ".id ASC")
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// 帖子编辑与删除（仅发帖的 Agent）：
+//   - 发布后 PostEditWindowMinutes 分钟内可编辑正文、背景和话题
+//   - 每次编辑追加一条 PostRevision（首次编辑时先补存原文为版本 1），历史版本只增不改，公开可查
+//   - 编辑会刷新 updated_at，搜索索引随之重建
+//   - 删除为软删除，并减少 Agent 的发帖数；话题热度由 GetTopics 按未删除的帖子统计，删除后自动扣除
+//   - 删除时撤回发帖奖励（退回激励池，每日上限不返还），防止删帖重发刷奖励；奖励已被花掉时不能删除
+//   - 悬赏评选结束前，作为回答的帖子不能编辑或删除
+type PostEditService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewPostEditService(db *gorm.DB, cfg *config.Config) *PostEditService {
+	return &PostEditService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// PostEdit 编辑后的内容
+type PostEdit struct {
+	Content string
+	Context string
+	Topics  string
+	Preview *string // 付费/持仓帖子的预览（nil 表示不修改）
+}
+
+// Edit 编辑帖子并保存历史版本
+func (s *PostEditService) Edit(agentID uint, postID uint, edit PostEdit) (*models.Post, error) {
+	post, err := s.ownPost(agentID, postID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(post.PostedAt) > time.Duration(s.cfg.PostEditWindowMinutes)*time.Minute {
+		return nil, errors.New("edit window has expired")
+	}
+	if s.answersOpenBounty(post) {
+		return nil, errors.New("answers to an open bounty cannot be edited")
+	}
+
+	preview := post.Preview
+	if edit.Preview != nil && post.GateType != "" {
+		gate, err := NormalizeGate(edit.Content, GateInput{
+			GateType:    post.GateType,
+			GateScope:   post.GateScope,
+			UnlockPrice: post.UnlockPrice,
+			HoldAmount:  post.HoldAmount,
+			Preview:     *edit.Preview,
+		})
+		if err != nil {
+			return nil, err
+		}
+		preview = gate.Preview
+	}
+	if edit.Content == post.Content && edit.Context == post.Context && edit.Topics == post.Topics && preview == post.Preview {
+		return post, nil
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var versions int64
+		if err := tx.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).Count(&versions).Error; err != nil {
+			return err
+		}
+		if versions == 0 {
+			original := models.PostRevision{
+				PostID:    post.ID,
+				Version:   1,
+				Content:   post.Content,
+				Context:   post.Context,
+				Topics:    post.Topics,
+				CreatedAt: post.PostedAt,
+			}
+			if err := tx.Create(&original).Error; err != nil {
+				return err
+			}
+			versions = 1
+		}
+		revision := models.PostRevision{
+			PostID:    post.ID,
+			Version:   int(versions) + 1,
+			Content:   edit.Content,
+			Context:   edit.Context,
+			Topics:    edit.Topics,
+			CreatedAt: now,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		return tx.Model(post).Updates(map[string]interface{}{
+			"content":   edit.Content,
+			"context":   edit.Context,
+			"topics":    edit.Topics,
+			"preview":   preview,
+			"edited":    true,
+			"edited_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	post.Content, post.Context, post.Topics, post.Preview = edit.Content, edit.Context, edit.Topics, preview
+	post.Edited, post.EditedAt = true, &now
+
+	// 立即重建搜索索引（失败时由后台任务按 updated_at 补建）
+	NewSearchService(s.db, s.cfg).IndexPost(post)
+	return post, nil
+}
+
+// Delete 软删除帖子、减少 Agent 发帖数并撤回发帖奖励
+func (s *PostEditService) Delete(agentID uint, postID uint) error {
+	post, err := s.ownPost(agentID, postID)
+	if err != nil {
+		return err
+	}
+	if s.answersOpenBounty(post) {
+		return errors.New("answers to an open bounty cannot be deleted")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 并发删除时只有一个请求会真正删除并扣减计数
+		result := tx.Delete(post)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("post not found")
+		}
+
+		var reward models.Reward
+		err := tx.Where("recipient_type = ? AND recipient_id = ? AND reward_type = ? AND reference_type = ? AND reference_id = ? AND status = ?",
+			"agent", agentID, RewardTypePost, "post", post.ID, RewardStatusGranted).First(&reward).Error
+		if err == nil {
+			if err := NewRewardService(s.db, s.cfg).RevokeAgentReward(tx, &reward, "post deleted"); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Model(&models.Agent{}).Where("id = ?", agentID).
+			UpdateColumn("posts_count", gorm.Expr("GREATEST(posts_count - 1, 0)")).Error
+	})
+}
+
+// Revisions 帖子的历史版本（从原文开始；未编辑过的帖子为空）
+func (s *PostEditService) Revisions(postID uint) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	err := s.db.Where("post_id = ?", postID).Order("version").Find(&revisions).Error
+	return revisions, err
+}
+
+// answersOpenBounty 帖子是否为仍在评选中的悬赏的回答
+func (s *PostEditService) answersOpenBounty(post *models.Post) bool {
+	if post.BountyID == nil {
+		return false
+	}
+	var bounty models.Bounty
+	return s.db.Select("status").First(&bounty, *post.BountyID).Error == nil && bounty.Status == "open"
+}
+
+// ownPost 查找 Agent 自己的帖子
+func (s *PostEditService) ownPost(agentID uint, postID uint) (*models.Post, error) {
+	var post models.Post
+	if err := s.db.Where("id = ? AND agent_id = ?", postID, agentID).First(&post).Error; err != nil {
+		return nil, errors.New("post not found")
+	}
+	return &post, nil
+}
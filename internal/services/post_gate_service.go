@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 帖子解锁类型
+const (
+	GateTypePay  = "pay"
+	GateTypeHold = "hold"
+)
+
+// 付费/持仓解锁：
+//   - pay：支付 UnlockPrice 后永久可见，付款按打赏费率抽成后进入 Agent 余额，记为 unlock_fee 平台收入
+//   - hold：平台内持有（可用 + 未解锁 + 质押）不少于 HoldAmount 的钱包可见，不产生扣款
+//   - 未解锁时：scope=media 隐藏图片视频；scope=all 同时把正文替换为预览
+type PostGateService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewPostGateService(db *gorm.DB, cfg *config.Config) *PostGateService {
+	return &PostGateService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// GateInput 发帖时的解锁设置
+type GateInput struct {
+	GateType    string
+	GateScope   string
+	UnlockPrice decimal.Decimal
+	HoldAmount  decimal.Decimal
+	Preview     string
+}
+
+// NormalizeGate 校验发帖时的解锁设置，补齐默认范围和预览
+func NormalizeGate(content string, input GateInput) (GateInput, error) {
+	if input.GateType == "" {
+		return GateInput{}, nil
+	}
+	if input.GateScope == "" {
+		input.GateScope = "all"
+	}
+	if input.GateScope != "media" && input.GateScope != "all" {
+		return input, errors.New("gateScope must be media or all")
+	}
+
+	switch input.GateType {
+	case GateTypePay:
+		if !input.UnlockPrice.IsPositive() {
+			return input, errors.New("unlockPrice must be positive")
+		}
+		input.HoldAmount = decimal.Zero
+	case GateTypeHold:
+		if !input.HoldAmount.IsPositive() {
+			return input, errors.New("holdAmount must be positive")
+		}
+		input.UnlockPrice = decimal.Zero
+	default:
+		return input, errors.New("gate must be pay or hold")
+	}
+
+	input.Preview = strings.TrimSpace(input.Preview)
+	if utf8.RuneCountInString(input.Preview) > 200 {
+		return input, errors.New("preview must be at most 200 characters")
+	}
+	if input.Preview == "" && input.GateScope == "all" {
+		// 默认取正文前 1/3（最多 60 字）
+		runes := []rune(content)
+		n := len(runes) / 3
+		if n > 60 {
+			n = 60
+		}
+		input.Preview = string(runes[:n]) + "…"
+	}
+	return input, nil
+}
+
+// Redact 按查看者隐藏未解锁的内容（walletAddress 为空表示游客）
+func (s *PostGateService) Redact(posts []models.Post, walletAddress string) {
+	walletAddress = strings.ToLower(walletAddress)
+
+	var payIDs []uint
+	needHoldings := false
+	for _, p := range posts {
+		switch p.GateType {
+		case GateTypePay:
+			payIDs = append(payIDs, p.ID)
+		case GateTypeHold:
+			needHoldings = true
+		}
+	}
+	if len(payIDs) == 0 && !needHoldings {
+		return
+	}
+
+	unlocked := make(map[uint]bool)
+	holdings := decimal.Zero
+	if walletAddress != "" {
+		if len(payIDs) > 0 {
+			var ids []uint
+			s.db.Model(&models.PostUnlock{}).
+				Where("wallet_address = ? AND post_id IN ?", walletAddress, payIDs).
+				Pluck("post_id", &ids)
+			for _, id := range ids {
+				unlocked[id] = true
+			}
+		}
+		if needHoldings {
+			holdings = s.Holdings(walletAddress)
+		}
+	}
+
+	for i := range posts {
+		p := &posts[i]
+		switch p.GateType {
+		case GateTypePay:
+			if unlocked[p.ID] {
+				continue
+			}
+		case GateTypeHold:
+			if walletAddress != "" && holdings.GreaterThanOrEqual(p.HoldAmount) {
+				continue
+			}
+		default:
+			continue
+		}
+		redactPost(p)
+	}
+}
+
+// RedactOne 处理单个帖子
+func (s *PostGateService) RedactOne(post *models.Post, walletAddress string) {
+	posts := []models.Post{*post}
+	s.Redact(posts, walletAddress)
+	*post = posts[0]
+}
+
+// redactPost 隐藏未解锁内容
+func redactPost(p *models.Post) {
+	p.Locked = true
+	p.LockedMedia = len(p.Images) + len(p.Videos)
+	p.Images = nil
+	p.Videos = nil
+	if p.GateScope != "media" {
+		p.Content = p.Preview
+		p.Context = ""
+	}
+}
+
+// Holdings 钱包在平台内的持仓（可用 + 未解锁 + 质押）
+func (s *PostGateService) Holdings(walletAddress string) decimal.Decimal {
+	var balance models.TokenBalance
+	if err := s.db.Where("wallet_address = ?", strings.ToLower(walletAddress)).First(&balance).Error; err != nil {
+		return decimal.Zero
+	}
+	return balance.Balance.Add(balance.VestingBalance).Add(balance.StakedBalance)
+}
+
+// Unlock 付费解锁帖子
+func (s *PostGateService) Unlock(walletAddress string, postID uint) (*models.PostUnlock, error) {
+	walletAddress = strings.ToLower(walletAddress)
+
+	var post models.Post
+	if err := s.db.First(&post, postID).Error; err != nil {
+		return nil, errors.New("post not found")
+	}
+	if post.GateType != GateTypePay {
+		return nil, errors.New("post does not require payment")
+	}
+
+	var unlock *models.PostUnlock
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		tx.Model(&models.PostUnlock{}).Where("post_id = ? AND wallet_address = ?", post.ID, walletAddress).Count(&count)
+		if count > 0 {
+			return errors.New("post already unlocked")
+		}
+
+		if _, err := spendUserTokens(tx, s.cfg, walletAddress, post.UnlockPrice); err != nil {
+			return err
+		}
+		quote, err := payAgent(tx, s.cfg, FeeInput{
+			FeeType:     FeeTypeTip,
+			PayerType:   "user",
+			PayerWallet: walletAddress,
+			AgentID:     post.AgentID,
+			Amount:      post.UnlockPrice,
+		})
+		if err != nil {
+			return err
+		}
+
+		unlock = &models.PostUnlock{
+			PostID:        post.ID,
+			WalletAddress: walletAddress,
+			AgentID:       post.AgentID,
+			Amount:        post.UnlockPrice,
+			PlatformFee:   quote.Fee,
+			AgentReceived: quote.NetAmount,
+		}
+		// 唯一索引兜底并发重复解锁
+		if err := tx.Create(unlock).Error; err != nil {
+			return errors.New("post already unlocked")
+		}
+		if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).
+			UpdateColumn("unlocks_count", gorm.Expr("unlocks_count + 1")).Error; err != nil {
+			return err
+		}
+		return recordPlatformIncome(tx, "unlock_fee", quote.Fee, "post_unlock", unlock.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// GetUnlocks 获取用户的解锁凭证
+func (s *PostGateService) GetUnlocks(walletAddress string, limit int, offset int) ([]models.PostUnlock, int64, error) {
+	var unlocks []models.PostUnlock
+	var total int64
+
+	query := s.db.Model(&models.PostUnlock{}).Where("wallet_address = ?", strings.ToLower(walletAddress))
+	query.Count(&total)
+
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&unlocks).Error
+	return unlocks, total, err
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 储备金证明：
+//   - 负债 = 每个账户的 可用余额 + 锁定余额（提现中）+ 未解锁奖励 + 质押中 + 悬赏托管
+//   - 每个账户一个叶子，叶子按哈希排序以隐藏账户顺序，账户标识加随机盐防止被枚举
+//   - 储备 = 平台钱包 + 所有已分配充值地址在同一区块的代币余额
+//   - 用户拿到自己的盐和证明后可以自行计算叶子哈希并逐层校验到根
+const (
+	reserveTokenDecimals = 18 // 与充值/提现一致
+	reserveKeepSnapshots = 28 // 保留最近N次快照的叶子（默认每6小时一次，约一周）
+)
+
+// ERC20 balanceOf(address) 函数选择器
+var balanceOfSelector = crypto.Keccak256([]byte("balanceOf(address)"))[:4]
+
+type ReserveService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewReserveService(db *gorm.DB, cfg *config.Config) *ReserveService {
+	return &ReserveService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// ReserveProof 账户的包含证明
+type ReserveProof struct {
+	SnapshotID       uint                 `json:"snapshotId"`
+	GeneratedAt      time.Time            `json:"generatedAt"`
+	MerkleRoot       string               `json:"merkleRoot"`
+	TotalLiabilities string               `json:"totalLiabilities"` // 根节点的和（最小单位）
+	AccountKey       string               `json:"accountKey"`       // 叶子中的账户标识
+	Salt             string               `json:"salt"`
+	Balance          decimal.Decimal      `json:"balance"`
+	BalanceRaw       string               `json:"balanceRaw"`
+	LeafHash         string               `json:"leafHash"`
+	Proof            []MerkleSumProofItem `json:"proof"`
+	Verified         bool                 `json:"verified"` // 服务端自检结果
+}
+
+// reserveAccount 单个账户的负债
+type reserveAccount struct {
+	accountType string
+	accountID   string
+	balance     decimal.Decimal
+}
+
+// GenerateSnapshot 生成一次储备金证明
+func (s *ReserveService) GenerateSnapshot() (*models.ReserveSnapshot, error) {
+	accounts, err := s.collectLiabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建叶子
+	leaves := make([]models.ReserveLeaf, 0, len(accounts))
+	userTotal := decimal.Zero
+	agentTotal := decimal.Zero
+	for _, a := range accounts {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		raw := a.balance.Shift(reserveTokenDecimals).BigInt()
+		node := MerkleSumLeaf(reserveAccountKey(a.accountType, a.accountID), salt, raw)
+
+		leaves = append(leaves, models.ReserveLeaf{
+			AccountType: a.accountType,
+			AccountID:   a.accountID,
+			Salt:        hexutil.Encode(salt),
+			Balance:     a.balance,
+			BalanceRaw:  raw.String(),
+			Hash:        node.Hash.Hex(),
+		})
+		if a.accountType == "agent" {
+			agentTotal = agentTotal.Add(a.balance)
+		} else {
+			userTotal = userTotal.Add(a.balance)
+		}
+	}
+
+	// 按叶子哈希排序，避免暴露账户顺序
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].Hash < leaves[j].Hash })
+
+	nodes := make([]MerkleSumNode, len(leaves))
+	for i := range leaves {
+		raw, _ := new(big.Int).SetString(leaves[i].BalanceRaw, 10)
+		nodes[i] = MerkleSumNode{Hash: common.HexToHash(leaves[i].Hash), Sum: raw}
+	}
+	tree := NewMerkleSumTree(nodes)
+	root := tree.Root()
+
+	for i := range leaves {
+		proof := tree.Proof(i)
+		if !VerifyMerkleSumProof(nodes[i], proof, root) {
+			return nil, fmt.Errorf("reserve proof verification failed for %s #%s", leaves[i].AccountType, leaves[i].AccountID)
+		}
+		proofJSON, _ := json.Marshal(proof)
+		leaves[i].LeafIndex = i
+		leaves[i].Proof = string(proofJSON)
+	}
+
+	snapshot := models.ReserveSnapshot{
+		MerkleRoot:       root.Hash.Hex(),
+		TotalLiabilities: decimal.NewFromBigInt(root.Sum, -reserveTokenDecimals),
+		UserLiabilities:  userTotal,
+		AgentLiabilities: agentTotal,
+		AccountCount:     len(leaves),
+	}
+
+	// 对比链上持仓
+	if err := s.fillOnChainReserves(&snapshot); err != nil {
+		log.Printf("Failed to query on-chain reserves: %v", err)
+		snapshot.ChainError = err.Error()
+	}
+	if snapshot.TotalLiabilities.IsPositive() {
+		snapshot.ReserveRatio = snapshot.TotalReserves.Div(snapshot.TotalLiabilities).Round(6)
+	}
+	snapshot.Solvent = snapshot.ChainError == "" && snapshot.TotalReserves.GreaterThanOrEqual(snapshot.TotalLiabilities)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&snapshot).Error; err != nil {
+			return err
+		}
+		for i := range leaves {
+			leaves[i].SnapshotID = snapshot.ID
+		}
+		if len(leaves) > 0 {
+			if err := tx.CreateInBatches(leaves, 500).Error; err != nil {
+				return err
+			}
+		}
+		return s.pruneLeaves(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetLatestSnapshot 获取最新的储备金证明
+func (s *ReserveService) GetLatestSnapshot() (*models.ReserveSnapshot, error) {
+	var snapshot models.ReserveSnapshot
+	if err := s.db.Order("created_at desc").First(&snapshot).Error; err != nil {
+		return nil, errors.New("no proof of reserves yet")
+	}
+	return &snapshot, nil
+}
+
+// GetSnapshots 获取历史储备金证明
+func (s *ReserveService) GetSnapshots(limit int, offset int) ([]models.ReserveSnapshot, int64, error) {
+	var snapshots []models.ReserveSnapshot
+	var total int64
+
+	query := s.db.Model(&models.ReserveSnapshot{})
+	query.Count(&total)
+
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&snapshots).Error
+	return snapshots, total, err
+}
+
+// GetAccountProof 获取账户在最新快照中的包含证明
+func (s *ReserveService) GetAccountProof(accountType string, accountID string) (*ReserveProof, error) {
+	snapshot, err := s.GetLatestSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if accountType == "user" {
+		accountID = strings.ToLower(accountID)
+	}
+
+	var leaf models.ReserveLeaf
+	if err := s.db.Where("snapshot_id = ? AND account_type = ? AND account_id = ?", snapshot.ID, accountType, accountID).
+		First(&leaf).Error; err != nil {
+		return nil, errors.New("account not included in the latest snapshot (zero balance at snapshot time)")
+	}
+
+	var proof []MerkleSumProofItem
+	if err := json.Unmarshal([]byte(leaf.Proof), &proof); err != nil {
+		return nil, errors.New("invalid stored proof")
+	}
+
+	// 服务端按公开算法重新计算一次
+	salt, _ := hexutil.Decode(leaf.Salt)
+	raw, _ := new(big.Int).SetString(leaf.BalanceRaw, 10)
+	accountKey := reserveAccountKey(accountType, accountID)
+	node := MerkleSumLeaf(accountKey, salt, raw)
+	rootSum := snapshot.TotalLiabilities.Shift(reserveTokenDecimals).BigInt()
+	verified := VerifyMerkleSumProof(node, proof, MerkleSumNode{Hash: common.HexToHash(snapshot.MerkleRoot), Sum: rootSum})
+
+	return &ReserveProof{
+		SnapshotID:       snapshot.ID,
+		GeneratedAt:      snapshot.CreatedAt,
+		MerkleRoot:       snapshot.MerkleRoot,
+		TotalLiabilities: rootSum.String(),
+		AccountKey:       accountKey,
+		Salt:             leaf.Salt,
+		Balance:          leaf.Balance,
+		BalanceRaw:       leaf.BalanceRaw,
+		LeafHash:         leaf.Hash,
+		Proof:            proof,
+		Verified:         verified,
+	}, nil
+}
+
+// StartReserveProver 定期生成储备金证明（在单独goroutine中运行）
+func (s *ReserveService) StartReserveProver(ctx context.Context) {
+	if s.cfg.ReserveProofHours <= 0 {
+		return
+	}
+
+	run := func() {
+		snapshot, err := s.GenerateSnapshot()
+		if err != nil {
+			log.Printf("Failed to generate proof of reserves: %v", err)
+			return
+		}
+		if !snapshot.Solvent {
+			log.Printf("⚠️ Proof of reserves #%d: reserves %s < liabilities %s (chain error: %s)",
+				snapshot.ID, snapshot.TotalReserves.String(), snapshot.TotalLiabilities.String(), snapshot.ChainError)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(time.Duration(s.cfg.ReserveProofHours) * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Reserve prover stopped")
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// collectLiabilities 汇总所有托管余额
+func (s *ReserveService) collectLiabilities() ([]reserveAccount, error) {
+	var accounts []reserveAccount
+
+	var userBalances []models.TokenBalance
+	if err := s.db.Find(&userBalances).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range userBalances {
+		total := b.Balance.Add(b.LockedBalance).Add(b.VestingBalance).Add(b.StakedBalance).Add(b.EscrowBalance)
+		if !total.IsPositive() {
+			continue
+		}
+		accounts = append(accounts, reserveAccount{"user", strings.ToLower(b.WalletAddress), total})
+	}
+
+	var agentBalances []models.AgentTokenBalance
+	if err := s.db.Find(&agentBalances).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range agentBalances {
+		total := b.Balance.Add(b.LockedBalance).Add(b.VestingBalance)
+		if !total.IsPositive() {
+			continue
+		}
+		accounts = append(accounts, reserveAccount{"agent", fmt.Sprint(b.AgentID), total})
+	}
+
+	return accounts, nil
+}
+
+// fillOnChainReserves 查询平台钱包和充值地址在同一区块的代币余额
+func (s *ReserveService) fillOnChainReserves(snapshot *models.ReserveSnapshot) error {
+	client, err := ethclient.Dial(s.cfg.BSCNodeURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to BSC node: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	block := header.Number
+	tokenAddr := common.HexToAddress(s.cfg.TokenContractAddr)
+
+	if s.cfg.PlatformWallet != "" {
+		balance, err := erc20BalanceAt(ctx, client, tokenAddr, common.HexToAddress(s.cfg.PlatformWallet), block)
+		if err != nil {
+			return fmt.Errorf("platform wallet: %v", err)
+		}
+		snapshot.PlatformReserves = balance
+	}
+
+	var addresses []models.DepositAddress
+	if err := s.db.Where("assigned_to IS NOT NULL AND assigned_to != ''").Find(&addresses).Error; err != nil {
+		return err
+	}
+	depositTotal := decimal.Zero
+	for _, addr := range addresses {
+		balance, err := erc20BalanceAt(ctx, client, tokenAddr, common.HexToAddress(addr.Address), block)
+		if err != nil {
+			return fmt.Errorf("deposit address %s: %v", addr.Address, err)
+		}
+		depositTotal = depositTotal.Add(balance)
+	}
+
+	now := time.Now()
+	snapshot.DepositReserves = depositTotal
+	snapshot.DepositAddresses = len(addresses)
+	snapshot.TotalReserves = snapshot.PlatformReserves.Add(depositTotal)
+	snapshot.ChainBlock = block.Uint64()
+	snapshot.ChainCheckedAt = &now
+	return nil
+}
+
+// pruneLeaves 清理旧快照的叶子（快照汇总保留）
+func (s *ReserveService) pruneLeaves(tx *gorm.DB) error {
+	var cutoff models.ReserveSnapshot
+	if err := tx.Order("id desc").Offset(reserveKeepSnapshots).First(&cutoff).Error; err != nil {
+		return nil // 快照数量未超过保留数
+	}
+	return tx.Unscoped().Where("snapshot_id <= ?", cutoff.ID).Delete(&models.ReserveLeaf{}).Error
+}
+
+// erc20BalanceAt 查询指定区块的 ERC20 余额
+func erc20BalanceAt(ctx context.Context, client *ethclient.Client, token common.Address, owner common.Address, block *big.Int) (decimal.Decimal, error) {
+	data := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(owner.Bytes(), 32)...)
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, block)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromBigInt(new(big.Int).SetBytes(result), -reserveTokenDecimals), nil
+}
+
+// reserveAccountKey 叶子中的账户标识
+func reserveAccountKey(accountType string, accountID string) string {
+	return accountType + ":" + accountID
+}
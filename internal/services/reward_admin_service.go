@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ==================== 奖励配置与激励池管理（管理员）====================
+
+// RewardConfigUpdate 奖励配置修改项（nil 表示不修改）
+type RewardConfigUpdate struct {
+	Amount      *decimal.Decimal
+	DailyLimit  *int
+	TotalLimit  *int
+	IsActive    *bool
+	VestingDays *int
+	PoolName    *string
+	Description *string
+}
+
+// ListRewardConfigs 获取所有奖励配置
+func (s *RewardService) ListRewardConfigs() ([]models.RewardConfig, error) {
+	var configs []models.RewardConfig
+	err := s.db.Order("id asc").Find(&configs).Error
+	return configs, err
+}
+
+// UpdateRewardConfig 修改奖励配置
+func (s *RewardService) UpdateRewardConfig(rewardType string, update RewardConfigUpdate, actor AdminActor) (*models.RewardConfig, error) {
+	var cfg models.RewardConfig
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reward_type = ?", rewardType).First(&cfg).Error; err != nil {
+			return errors.New("reward type not found")
+		}
+		before := cfg
+
+		if update.Amount != nil {
+			if update.Amount.IsNegative() {
+				return errors.New("amount must not be negative")
+			}
+			cfg.Amount = *update.Amount
+		}
+		if update.DailyLimit != nil {
+			if *update.DailyLimit < 0 {
+				return errors.New("dailyLimit must not be negative")
+			}
+			cfg.DailyLimit = *update.DailyLimit
+		}
+		if update.TotalLimit != nil {
+			if *update.TotalLimit < 0 {
+				return errors.New("totalLimit must not be negative")
+			}
+			cfg.TotalLimit = *update.TotalLimit
+		}
+		if update.IsActive != nil {
+			cfg.IsActive = *update.IsActive
+		}
+		if update.VestingDays != nil {
+			if *update.VestingDays < 0 || *update.VestingDays > 3650 {
+				return errors.New("vestingDays must be between 0 and 3650")
+			}
+			cfg.VestingDays = *update.VestingDays
+		}
+		if update.PoolName != nil {
+			var pool models.RewardPool
+			if err := tx.Where("name = ?", *update.PoolName).First(&pool).Error; err != nil {
+				return errors.New("reward pool not found")
+			}
+			cfg.PoolName = pool.Name
+		}
+		if update.Description != nil {
+			cfg.Description = *update.Description
+		}
+
+		if err := tx.Save(&cfg).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, "reward_config.update", "reward_config", cfg.RewardType, before, cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ListRewardPools 获取所有激励池
+func (s *RewardService) ListRewardPools() ([]models.RewardPool, error) {
+	var pools []models.RewardPool
+	err := s.db.Order("id asc").Find(&pools).Error
+	return pools, err
+}
+
+// CreateRewardPool 创建激励池（初始余额记为一笔手动注入）
+func (s *RewardService) CreateRewardPool(name string, initialBalance decimal.Decimal, note string, actor AdminActor) (*models.RewardPool, error) {
+	if initialBalance.IsNegative() {
+		return nil, errors.New("initial balance must not be negative")
+	}
+
+	pool := models.RewardPool{
+		Name:             name,
+		Balance:          decimal.Zero,
+		TotalDeposited:   decimal.Zero,
+		TotalDistributed: decimal.Zero,
+		IsActive:         true,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.RewardPool
+		if err := tx.Where("name = ?", name).First(&existing).Error; err == nil {
+			return errors.New("reward pool already exists")
+		}
+
+		if err := tx.Create(&pool).Error; err != nil {
+			return err
+		}
+		if initialBalance.IsPositive() {
+			if _, err := s.depositToPool(tx, name, initialBalance, "manual", "", note); err != nil {
+				return err
+			}
+			tx.First(&pool, pool.ID)
+		}
+		return RecordAudit(tx, actor, "pool.create", "reward_pool", pool.Name, nil, pool)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// SetRewardPoolActive 启用/停用激励池
+func (s *RewardService) SetRewardPoolActive(name string, active bool, actor AdminActor) (*models.RewardPool, error) {
+	var pool models.RewardPool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", name).First(&pool).Error; err != nil {
+			return errors.New("reward pool not found")
+		}
+		before := pool
+
+		pool.IsActive = active
+		if err := tx.Save(&pool).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, actor, "pool.update", "reward_pool", pool.Name, before, pool)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// AdminDepositToPool 管理员向激励池注入资金
+func (s *RewardService) AdminDepositToPool(poolName string, amount decimal.Decimal, source string, txHash string, note string, actor AdminActor) (*models.RewardPoolDeposit, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be positive")
+	}
+	if source == "" {
+		source = "manual"
+	}
+
+	var deposit *models.RewardPoolDeposit
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var before models.RewardPool
+		if err := tx.Where("name = ?", poolName).First(&before).Error; err != nil {
+			return errors.New("reward pool not found")
+		}
+
+		var err error
+		deposit, err = s.depositToPool(tx, poolName, amount, source, txHash, note)
+		if err != nil {
+			return err
+		}
+
+		var after models.RewardPool
+		tx.First(&after, before.ID)
+		return RecordAudit(tx, actor, "pool.deposit", "reward_pool", poolName, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deposit, nil
+}
+
+// GetPoolDeposits 获取激励池注入记录
+func (s *RewardService) GetPoolDeposits(poolName string, limit int, offset int) ([]models.RewardPoolDeposit, int64, error) {
+	var pool models.RewardPool
+	if err := s.db.Where("name = ?", poolName).First(&pool).Error; err != nil {
+		return nil, 0, fmt.Errorf("reward pool %s not found", poolName)
+	}
+
+	var deposits []models.RewardPoolDeposit
+	var total int64
+
+	query := s.db.Model(&models.RewardPoolDeposit{}).Where("pool_id = ?", pool.ID)
+	query.Count(&total)
+
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&deposits).Error
+	return deposits, total, err
+}
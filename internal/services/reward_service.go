@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
@@ -22,6 +24,14 @@ const (
 	RewardTypeHotPost     = "hot_post"     // 热帖奖励
 )
 
+// 奖励状态
+const (
+	RewardStatusGranted  = "granted"  // 已发放
+	RewardStatusDeferred = "deferred" // 风控延迟，等待审核
+	RewardStatusRejected = "rejected" // 审核拒绝
+	RewardStatusRevoked  = "revoked"  // 发放后撤回（如帖子被删除）
+)
+
 // 每日发放上限（100亿代币）
 const DailyDistributionCap = 10_000_000_000
 
@@ -92,30 +102,36 @@ func (s *RewardService) GetRewardPool(name string) (*models.RewardPool, error) {
 // DepositToPool 向激励池注入资金
 func (s *RewardService) DepositToPool(poolName string, amount decimal.Decimal, source string, txHash string, note string) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		var pool models.RewardPool
-		if err := tx.Where("name = ?", poolName).First(&pool).Error; err != nil {
-			return err
-		}
-		
-		pool.Balance = pool.Balance.Add(amount)
-		pool.TotalDeposited = pool.TotalDeposited.Add(amount)
-		
-		if err := tx.Save(&pool).Error; err != nil {
-			return err
-		}
-		
-		deposit := models.RewardPoolDeposit{
-			PoolID: pool.ID,
-			Amount: amount,
-			Source: source,
-			TxHash: txHash,
-			Note:   note,
-		}
-		
-		return tx.Create(&deposit).Error
+		_, err := s.depositToPool(tx, poolName, amount, source, txHash, note)
+		return err
 	})
 }
 
+// depositToPool 注入资金（事务内）
+func (s *RewardService) depositToPool(tx *gorm.DB, poolName string, amount decimal.Decimal, source string, txHash string, note string) (*models.RewardPoolDeposit, error) {
+	var pool models.RewardPool
+	if err := tx.Where("name = ?", poolName).First(&pool).Error; err != nil {
+		return nil, err
+	}
+	
+	pool.Balance = pool.Balance.Add(amount)
+	pool.TotalDeposited = pool.TotalDeposited.Add(amount)
+	
+	if err := tx.Save(&pool).Error; err != nil {
+		return nil, err
+	}
+	
+	deposit := &models.RewardPoolDeposit{
+		PoolID: pool.ID,
+		Amount: amount,
+		Source: source,
+		TxHash: txHash,
+		Note:   note,
+	}
+	
+	return deposit, tx.Create(deposit).Error
+}
+
 // GrantReward 发放奖励
 func (s *RewardService) GrantReward(recipientType string, recipientID uint, recipientWallet string, rewardType string, referenceType string, referenceID uint) (*models.Reward, error) {
 	// 获取奖励配置
@@ -126,7 +142,7 @@ func (s *RewardService) GrantReward(recipientType string, recipientID uint, reci
 	
 	// 检查每日限制
 	if cfg.DailyLimit > 0 {
-		canClaim, err := s.checkDailyLimit(recipientWallet, rewardType, cfg.DailyLimit)
+		canClaim, err := s.checkDailyLimit(recipientType, recipientID, recipientWallet, rewardType, cfg.DailyLimit)
 		if err != nil {
 			return nil, err
 		}
@@ -135,6 +151,17 @@ func (s *RewardService) GrantReward(recipientType string, recipientID uint, reci
 		}
 	}
 	
+	// 检查累计上限
+	if cfg.TotalLimit > 0 {
+		canClaim, err := s.checkTotalLimit(recipientType, recipientID, recipientWallet, rewardType, cfg.TotalLimit)
+		if err != nil {
+			return nil, err
+		}
+		if !canClaim {
+			return nil, errors.New("total limit reached")
+		}
+	}
+	
 	// 检查全局每日发放上限
 	todayTotal, err := s.getTodayDistributedTotal()
 	if err != nil {
@@ -144,97 +171,297 @@ func (s *RewardService) GrantReward(recipientType string, recipientID uint, reci
 		return nil, errors.New("daily distribution cap reached, try again tomorrow")
 	}
 	
-	var reward *models.Reward
+	// 风控评分（仅针对用户钱包）
+	amount := cfg.Amount
+	status := RewardStatusGranted
+	riskScore := -1
+	if recipientType == "user" && s.cfg.RiskEnabled {
+		decision, err := NewRiskService(s.db, s.cfg).Assess(recipientWallet)
+		if err != nil {
+			log.Printf("Risk assessment failed for %s: %v", recipientWallet, err)
+		} else {
+			riskScore = decision.Score
+			switch decision.Action {
+			case RiskActionReduce:
+				amount = amount.Mul(decision.Multiplier).Round(18)
+			case RiskActionDefer:
+				status = RewardStatusDeferred
+			}
+		}
+	}
+	
+	// 匹配进行中的奖励活动（延迟发放的不参与）
+	var campaign *models.RewardCampaign
+	if status == RewardStatusGranted {
+		campaign = NewCampaignService(s.db, s.cfg).FindCampaign(rewardType, referenceType, referenceID, recipientType, recipientID)
+	}
+	
+	reward := &models.Reward{
+		RecipientType:   recipientType,
+		RecipientID:     recipientID,
+		RecipientWallet: recipientWallet,
+		RewardType:      rewardType,
+		Amount:          amount,
+		ReferenceType:   referenceType,
+		ReferenceID:     referenceID,
+		Status:          status,
+		RiskScore:       riskScore,
+	}
 	
 	err = s.db.Transaction(func(tx *gorm.DB) error {
-		// 获取激励池
-		var pool models.RewardPool
-		if err := tx.Where("name = ? AND is_active = ?", "main", true).First(&pool).Error; err != nil {
-			return errors.New("reward pool not found")
+		// 延迟发放：只记录，不扣激励池、不入账，等待管理员审核
+		if status == RewardStatusDeferred {
+			reward.Note = "deferred by risk control"
+			if err := tx.Create(reward).Error; err != nil {
+				return err
+			}
+			return s.updateDailyRewardCount(tx, recipientType, recipientID, recipientWallet, rewardType)
 		}
 		
-		// 检查激励池余额是否低于阈值
-		if pool.Balance.LessThan(decimal.NewFromInt(PoolLowBalanceThreshold)) {
-			return errors.New("reward pool balance too low, distribution paused")
+		// 活动加成（占用活动预算）
+		if campaign != nil {
+			bonus, err := NewCampaignService(s.db, s.cfg).ReserveBonus(tx, campaign, amount)
+			if err != nil {
+				return err
+			}
+			if bonus.IsPositive() {
+				reward.CampaignID = campaign.ID
+				reward.BonusAmount = bonus
+				reward.Amount = amount.Add(bonus)
+			}
 		}
 		
-		// 检查激励池余额是否足够本次发放
-		if pool.Balance.LessThan(cfg.Amount) {
-			return errors.New("insufficient reward pool balance")
+		pool, err := s.debitPool(tx, cfg.PoolName, reward.Amount)
+		if err != nil {
+			return err
 		}
 		
-		// 扣减激励池
-		pool.Balance = pool.Balance.Sub(cfg.Amount)
-		pool.TotalDistributed = pool.TotalDistributed.Add(cfg.Amount)
-		if err := tx.Save(&pool).Error; err != nil {
+		// 创建奖励记录
+		now := time.Now()
+		reward.PoolID = pool.ID
+		reward.GrantedAt = &now
+		if err := tx.Create(reward).Error; err != nil {
 			return err
 		}
 		
-		// 增加接收者余额
-		if recipientType == "user" {
-			var balance models.TokenBalance
-			err := tx.Where("wallet_address = ?", recipientWallet).First(&balance).Error
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				balance = models.TokenBalance{
-					WalletAddress: recipientWallet,
-					Balance:       decimal.Zero,
-				}
-			} else if err != nil {
-				return err
-			}
-			
-			balance.Balance = balance.Balance.Add(cfg.Amount)
-			balance.TotalRewards = balance.TotalRewards.Add(cfg.Amount)
-			if err := tx.Save(&balance).Error; err != nil {
-				return err
-			}
-		} else if recipientType == "agent" {
-			var balance models.AgentTokenBalance
-			err := tx.Where("agent_id = ?", recipientID).First(&balance).Error
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				balance = models.AgentTokenBalance{
-					AgentID: recipientID,
-					Balance: decimal.Zero,
-				}
-			} else if err != nil {
-				return err
+		if err := s.creditRecipient(tx, reward, cfg.VestingDays); err != nil {
+			return err
+		}
+		
+		// 更新每日领取记录
+		return s.updateDailyRewardCount(tx, recipientType, recipientID, recipientWallet, rewardType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return reward, nil
+}
+
+// debitPool 从激励池扣减发放金额
+func (s *RewardService) debitPool(tx *gorm.DB, poolName string, amount decimal.Decimal) (*models.RewardPool, error) {
+	if poolName == "" {
+		poolName = "main"
+	}
+	
+	var pool models.RewardPool
+	if err := tx.Where("name = ? AND is_active = ?", poolName, true).First(&pool).Error; err != nil {
+		return nil, errors.New("reward pool not found")
+	}
+	
+	// 检查激励池余额是否低于阈值
+	if pool.Balance.LessThan(decimal.NewFromInt(PoolLowBalanceThreshold)) {
+		return nil, errors.New("reward pool balance too low, distribution paused")
+	}
+	
+	// 检查激励池余额是否足够本次发放
+	if pool.Balance.LessThan(amount) {
+		return nil, errors.New("insufficient reward pool balance")
+	}
+	
+	pool.Balance = pool.Balance.Sub(amount)
+	pool.TotalDistributed = pool.TotalDistributed.Add(amount)
+	if err := tx.Save(&pool).Error; err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// creditRecipient 奖励入账
+// 开启解锁模式且该奖励类型配置了解锁天数时，计入未解锁余额并创建解锁计划
+func (s *RewardService) creditRecipient(tx *gorm.DB, reward *models.Reward, vestingDays int) error {
+	vesting := s.cfg.RewardVestingEnabled && vestingDays > 0
+	
+	if reward.RecipientType == "user" {
+		var balance models.TokenBalance
+		err := tx.Where("wallet_address = ?", reward.RecipientWallet).First(&balance).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			balance = models.TokenBalance{
+				WalletAddress: reward.RecipientWallet,
+				Balance:       decimal.Zero,
 			}
-			
-			balance.Balance = balance.Balance.Add(cfg.Amount)
-			balance.TotalRewards = balance.TotalRewards.Add(cfg.Amount)
-			if err := tx.Save(&balance).Error; err != nil {
-				return err
+		} else if err != nil {
+			return err
+		}
+		
+		if vesting {
+			balance.VestingBalance = balance.VestingBalance.Add(reward.Amount)
+		} else {
+			balance.Balance = balance.Balance.Add(reward.Amount)
+		}
+		balance.TotalRewards = balance.TotalRewards.Add(reward.Amount)
+		if err := tx.Save(&balance).Error; err != nil {
+			return err
+		}
+	} else if reward.RecipientType == "agent" {
+		var balance models.AgentTokenBalance
+		err := tx.Where("agent_id = ?", reward.RecipientID).First(&balance).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			balance = models.AgentTokenBalance{
+				AgentID: reward.RecipientID,
+				Balance: decimal.Zero,
 			}
+		} else if err != nil {
+			return err
 		}
 		
-		// 创建奖励记录
-		reward = &models.Reward{
-			RecipientType:   recipientType,
-			RecipientID:     recipientID,
-			RecipientWallet: recipientWallet,
-			RewardType:      rewardType,
-			Amount:          cfg.Amount,
-			ReferenceType:   referenceType,
-			ReferenceID:     referenceID,
-			PoolID:          pool.ID,
+		if vesting {
+			balance.VestingBalance = balance.VestingBalance.Add(reward.Amount)
+		} else {
+			balance.Balance = balance.Balance.Add(reward.Amount)
 		}
-		if err := tx.Create(reward).Error; err != nil {
+		balance.TotalRewards = balance.TotalRewards.Add(reward.Amount)
+		if err := tx.Save(&balance).Error; err != nil {
+			return err
+		}
+	} else {
+		return nil
+	}
+	
+	if vesting {
+		return NewVestingService(s.db, s.cfg).CreateGrant(tx, reward, vestingDays)
+	}
+	return nil
+}
+
+// ReleaseDeferredReward 审核通过，发放被延迟的奖励
+func (s *RewardService) ReleaseDeferredReward(rewardID uint, note string, actor AdminActor) (*models.Reward, error) {
+	var reward models.Reward
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.claimDeferred(tx, rewardID, RewardStatusGranted, &reward); err != nil {
 			return err
 		}
 		
-		// 更新每日领取记录
-		return s.updateDailyRewardCount(tx, recipientWallet, rewardType)
+		// 配置缺失时不能按默认池和立即到账发放，否则会绕过原本的解锁规则
+		var cfg models.RewardConfig
+		if err := tx.Where("reward_type = ?", reward.RewardType).First(&cfg).Error; err != nil {
+			return fmt.Errorf("reward config %q not found", reward.RewardType)
+		}
+		
+		pool, err := s.debitPool(tx, cfg.PoolName, reward.Amount)
+		if err != nil {
+			return err
+		}
+		
+		now := time.Now()
+		reward.PoolID = pool.ID
+		reward.Note = note
+		reward.GrantedAt = &now
+		if err := tx.Save(&reward).Error; err != nil {
+			return err
+		}
+		if err := s.creditRecipient(tx, &reward, cfg.VestingDays); err != nil {
+			return err
+		}
+		
+		return RecordAudit(tx, actor, "reward.release", "reward", fmt.Sprint(reward.ID), nil, reward)
 	})
+	return &reward, err
+}
+
+// RejectDeferredReward 审核拒绝被延迟的奖励
+func (s *RewardService) RejectDeferredReward(rewardID uint, note string, actor AdminActor) (*models.Reward, error) {
+	var reward models.Reward
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.claimDeferred(tx, rewardID, RewardStatusRejected, &reward); err != nil {
+			return err
+		}
+		
+		reward.Note = note
+		if err := tx.Save(&reward).Error; err != nil {
+			return err
+		}
+		
+		return RecordAudit(tx, actor, "reward.reject", "reward", fmt.Sprint(reward.ID), nil, reward)
+	})
+	return &reward, err
+}
+
+// claimDeferred 把待审核奖励改为 status（条件更新，并发审核时只有一个能成功）并读出奖励
+func (s *RewardService) claimDeferred(tx *gorm.DB, rewardID uint, status string, reward *models.Reward) error {
+	result := tx.Model(&models.Reward{}).
+		Where("id = ? AND status = ?", rewardID, RewardStatusDeferred).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		if err := tx.First(reward, rewardID).Error; err != nil {
+			return errors.New("reward not found")
+		}
+		return errors.New("reward is not deferred")
+	}
+	return tx.First(reward, rewardID).Error
+}
+
+// RevokeAgentReward 撤回已发放给 Agent 的奖励：从 Agent 余额（优先未解锁部分）扣回并退回激励池
+// 撤回的奖励仍计入每日和累计上限；Agent 余额不足（已打赏或提现）时失败
+func (s *RewardService) RevokeAgentReward(tx *gorm.DB, reward *models.Reward, note string) error {
+	if reward.RecipientType != "agent" {
+		return errors.New("only agent rewards can be revoked")
+	}
+	result := tx.Model(&models.Reward{}).
+		Where("id = ? AND status = ?", reward.ID, RewardStatusGranted).
+		Updates(map[string]interface{}{"status": RewardStatusRevoked, "note": note})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errors.New("reward is not granted")
+	}
+
+	if err := debitAgentTokens(tx, s.cfg, reward.RecipientID, reward.Amount, "total_rewards", reward.Amount.Neg()); err != nil {
+		return errors.New("reward has already been spent")
+	}
+	reward.Status = RewardStatusRevoked
+	reward.Note = note
+
+	return tx.Model(&models.RewardPool{}).Where("id = ?", reward.PoolID).
+		UpdateColumns(map[string]interface{}{
+			"balance":           gorm.Expr("balance + ?", reward.Amount),
+			"total_distributed": gorm.Expr("total_distributed - ?", reward.Amount),
+		}).Error
+}
+
+// GetDeferredRewards 获取待审核的奖励
+func (s *RewardService) GetDeferredRewards(limit int, offset int) ([]models.Reward, int64, error) {
+	var rewards []models.Reward
+	var total int64
+	
+	query := s.db.Model(&models.Reward{}).Where("status = ?", RewardStatusDeferred)
+	query.Count(&total)
 	
-	return reward, err
+	err := query.Order("created_at asc").Limit(limit).Offset(offset).Find(&rewards).Error
+	return rewards, total, err
 }
 
 // checkDailyLimit 检查每日领取限制
-func (s *RewardService) checkDailyLimit(walletAddress string, rewardType string, limit int) (bool, error) {
+func (s *RewardService) checkDailyLimit(recipientType string, recipientID uint, walletAddress string, rewardType string, limit int) (bool, error) {
 	today := time.Now().Truncate(24 * time.Hour)
 	
 	var record models.UserDailyReward
-	err := s.db.Where("wallet_address = ? AND reward_type = ? AND date = ?", walletAddress, rewardType, today).First(&record).Error
+	err := s.db.Scopes(dailyRewardRecipient(recipientType, recipientID, walletAddress)).
+		Where("reward_type = ? AND date = ?", rewardType, today).First(&record).Error
 	
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return true, nil // 今天还没领取过
@@ -246,20 +473,42 @@ func (s *RewardService) checkDailyLimit(walletAddress string, rewardType string,
 	return record.Count < limit, nil
 }
 
+// checkTotalLimit 检查累计领取限制（被拒绝的不计入）
+func (s *RewardService) checkTotalLimit(recipientType string, recipientID uint, walletAddress string, rewardType string, limit int) (bool, error) {
+	query := s.db.Model(&models.Reward{}).
+		Where("reward_type = ? AND status <> ?", rewardType, RewardStatusRejected)
+	if recipientType == "agent" {
+		query = query.Where("recipient_type = ? AND recipient_id = ?", "agent", recipientID)
+	} else {
+		query = query.Where("recipient_type = ? AND recipient_wallet = ?", recipientType, walletAddress)
+	}
+	
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count < int64(limit), nil
+}
+
 // updateDailyRewardCount 更新每日领取次数
-func (s *RewardService) updateDailyRewardCount(tx *gorm.DB, walletAddress string, rewardType string) error {
+func (s *RewardService) updateDailyRewardCount(tx *gorm.DB, recipientType string, recipientID uint, walletAddress string, rewardType string) error {
 	today := time.Now().Truncate(24 * time.Hour)
 	
 	var record models.UserDailyReward
-	err := tx.Where("wallet_address = ? AND reward_type = ? AND date = ?", walletAddress, rewardType, today).First(&record).Error
+	err := tx.Scopes(dailyRewardRecipient(recipientType, recipientID, walletAddress)).
+		Where("reward_type = ? AND date = ?", rewardType, today).First(&record).Error
 	
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		record = models.UserDailyReward{
+			RecipientType: recipientType,
 			WalletAddress: walletAddress,
 			RewardType:    rewardType,
 			Date:          today,
 			Count:         1,
 		}
+		if recipientType == "agent" {
+			record.RecipientID = recipientID
+		}
 		return tx.Create(&record).Error
 	}
 	if err != nil {
@@ -270,12 +519,22 @@ func (s *RewardService) updateDailyRewardCount(tx *gorm.DB, walletAddress string
 	return tx.Save(&record).Error
 }
 
+// dailyRewardRecipient 每日领取记录的归属（Agent 没有钱包地址，按 ID 区分）
+func dailyRewardRecipient(recipientType string, recipientID uint, walletAddress string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if recipientType == "agent" {
+			return db.Where("recipient_type = ? AND recipient_id = ?", "agent", recipientID)
+		}
+		return db.Where("recipient_type = ? AND wallet_address = ?", "user", walletAddress)
+	}
+}
+
 // getTodayDistributedTotal 获取今日已发放总量
 func (s *RewardService) getTodayDistributedTotal() (decimal.Decimal, error) {
 	today := time.Now().Truncate(24 * time.Hour)
 	var total decimal.Decimal
 	err := s.db.Model(&models.Reward{}).
-		Where("created_at >= ?", today).
+		Where("created_at >= ? AND status = ?", today, RewardStatusGranted).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&total).Error
 	return total, err
@@ -293,6 +552,26 @@ func (s *RewardService) GetUserRewards(walletAddress string, limit int, offset i
 	return rewards, total, err
 }
 
+// GetUserRewardsByCursor 按游标获取用户奖励历史，返回下一页游标（没有更多时为空）
+func (s *RewardService) GetUserRewardsByCursor(walletAddress string, cursor *Cursor, limit int) ([]models.Reward, string, error) {
+	var rewards []models.Reward
+	err := s.db.Where("recipient_wallet = ?", walletAddress).
+		Scopes(KeysetByTime("rewards", "created_at", cursor)).
+		Limit(limit + 1).
+		Find(&rewards).Error
+	if err != nil {
+		return nil, "", err
+	}
+	
+	nextCursor := ""
+	if len(rewards) > limit {
+		rewards = rewards[:limit]
+		last := rewards[len(rewards)-1]
+		nextCursor = TimeCursor(last.CreatedAt, last.ID)
+	}
+	return rewards, nextCursor, nil
+}
+
 // GetAgentRewards 获取Agent奖励历史
 func (s *RewardService) GetAgentRewards(agentID uint, limit int, offset int) ([]models.Reward, int64, error) {
 	var rewards []models.Reward
@@ -316,7 +595,7 @@ func (s *RewardService) GetRewardStats() (map[string]interface{}, error) {
 	today := time.Now().Truncate(24 * time.Hour)
 	var todayTotal decimal.Decimal
 	s.db.Model(&models.Reward{}).
-		Where("created_at >= ?", today).
+		Where("created_at >= ? AND status = ?", today, RewardStatusGranted).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&todayTotal)
 	
@@ -0,0 +1,557 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 风控决策
+const (
+	RiskActionGrant  = "grant"  // 正常发放
+	RiskActionReduce = "reduce" // 打折发放
+	RiskActionDefer  = "defer"  // 延迟发放（人工审核）
+)
+
+// 指纹类型
+const (
+	FingerprintIP     = "ip"
+	FingerprintDevice = "device"
+)
+
+const (
+	riskRescoreInterval = 10 * time.Minute    // 评分缓存时间
+	riskChainInterval   = 24 * time.Hour      // 链上数据刷新间隔
+	riskFingerprintTTL  = 30 * 24 * time.Hour // 只统计最近30天的IP/设备
+	riskBurstWindow     = 10 * time.Minute    // 同簇集中领取的判定窗口
+
+	riskFingerprintQueueSize = 4096            // 指纹写入队列长度（满了直接丢弃）
+	riskFingerprintBatchSize = 500             // 每批最多写入的指纹数
+	riskFingerprintFlush     = 2 * time.Second // 指纹批量写入间隔
+)
+
+var (
+	// fingerprintQueue 待写入的指纹，由 StartFingerprintRecorder 批量落库
+	fingerprintQueue = make(chan models.WalletFingerprint, riskFingerprintQueueSize)
+
+	// 链上查询共用一个节点连接；riskChainPending 记录正在后台刷新链上数据的钱包
+	riskChainMu      sync.Mutex
+	riskChainClient  *ethclient.Client
+	riskChainPending sync.Map
+)
+
+// 评分时写回的字段（链上数据由后台刷新单独写入，避免互相覆盖）
+var riskScoreColumns = []string{"score", "flagged", "reasons", "shared_ip_wallets", "shared_device_wallets", "scored_at", "updated_at"}
+
+type RiskService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewRiskService(db *gorm.DB, cfg *config.Config) *RiskService {
+	return &RiskService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// RiskDecision 奖励发放决策
+type RiskDecision struct {
+	Action     string
+	Score      int
+	Multiplier decimal.Decimal
+}
+
+// Assess 评估钱包并给出奖励发放决策
+func (s *RiskService) Assess(walletAddress string) (*RiskDecision, error) {
+	profile, err := s.Evaluate(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &RiskDecision{
+		Action:     RiskActionGrant,
+		Score:      profile.Score,
+		Multiplier: decimal.NewFromInt(1),
+	}
+	switch {
+	case profile.Score < s.cfg.RiskDeferScore:
+		decision.Action = RiskActionDefer
+	case profile.Score < s.cfg.RiskReduceScore:
+		decision.Action = RiskActionReduce
+		decision.Multiplier = decimal.NewFromFloat(s.cfg.RiskReduceRate)
+	}
+	return decision, nil
+}
+
+// Evaluate 获取钱包风险评分（缓存过期则重新计算）
+// 链上数据过期时只在后台刷新，本次按已有数据评分，刷新完成后下次评估生效
+func (s *RiskService) Evaluate(walletAddress string) (*models.WalletRiskProfile, error) {
+	wallet := strings.ToLower(walletAddress)
+
+	var profile models.WalletRiskProfile
+	err := s.db.Where("wallet_address = ?", wallet).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		profile = models.WalletRiskProfile{
+			WalletAddress: wallet,
+			Score:         100,
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if profile.ID != 0 && time.Since(profile.ScoredAt) < riskRescoreInterval {
+		return &profile, nil
+	}
+
+	s.score(&profile)
+
+	if profile.ID == 0 {
+		err = s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "wallet_address"}},
+			DoUpdates: clause.AssignmentColumns(riskScoreColumns),
+		}).Create(&profile).Error
+	} else {
+		err = s.db.Model(&profile).Select(riskScoreColumns).Updates(&profile).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.scheduleChainRefresh(&profile)
+	return &profile, nil
+}
+
+// score 计算风险评分（满分100，按可疑特征扣分）
+func (s *RiskService) score(profile *models.WalletRiskProfile) {
+	score := 100
+	var reasons []string
+	penalize := func(points int, reason string) {
+		score -= points
+		reasons = append(reasons, reason)
+	}
+
+	// 1. 链上活跃度
+	if profile.ChainCheckedAt != nil {
+		switch {
+		case profile.ChainTxCount == 0:
+			penalize(25, "no_chain_activity")
+		case profile.ChainTxCount < 5:
+			penalize(10, "low_chain_activity")
+		}
+		if profile.ChainBalance.IsZero() {
+			penalize(5, "zero_gas_balance")
+		}
+	}
+
+	// 2. 钱包年龄
+	if profile.FirstTxAt != nil {
+		age := time.Since(*profile.FirstTxAt)
+		switch {
+		case age < 7*24*time.Hour:
+			penalize(20, "new_wallet")
+		case age < 30*24*time.Hour:
+			penalize(10, "young_wallet")
+		}
+	}
+
+	// 3. 平台账号年龄
+	var user models.User
+	if err := s.db.Where("wallet_address = ?", profile.WalletAddress).First(&user).Error; err == nil {
+		if time.Since(user.CreatedAt) < 24*time.Hour {
+			penalize(10, "new_account")
+		}
+	}
+
+	// 4. 共用IP/设备（IP可能是公司或运营商NAT，阈值放宽）
+	profile.SharedIPWallets = len(s.clusterWallets(profile.WalletAddress, FingerprintIP))
+	profile.SharedDeviceWallets = len(s.clusterWallets(profile.WalletAddress, FingerprintDevice))
+	if n := profile.SharedIPWallets; n >= 2 {
+		penalize(int(math.Min(30, float64(5*n))), "shared_ip")
+	}
+	if n := profile.SharedDeviceWallets; n >= 1 {
+		penalize(int(math.Min(40, float64(20*n))), "shared_device")
+	}
+
+	// 5. 时间规律
+	if s.hasRegularTiming(profile.WalletAddress) {
+		penalize(15, "regular_timing")
+	}
+	if s.hasClusterBurst(profile.WalletAddress) {
+		penalize(15, "cluster_burst")
+	}
+
+	if score < 0 {
+		score = 0
+	}
+	profile.Score = score
+	profile.Reasons = strings.Join(reasons, ",")
+	profile.Flagged = score < s.cfg.RiskReduceScore
+	profile.ScoredAt = time.Now()
+}
+
+// clusterWallets 与该钱包共用IP或设备的其他钱包
+func (s *RiskService) clusterWallets(walletAddress string, kind string) []string {
+	since := time.Now().Add(-riskFingerprintTTL)
+	values := s.db.Model(&models.WalletFingerprint{}).
+		Select("value").
+		Where("wallet_address = ? AND kind = ? AND last_seen_at >= ?", walletAddress, kind, since)
+
+	var wallets []string
+	s.db.Model(&models.WalletFingerprint{}).
+		Where("kind = ? AND value IN (?) AND wallet_address <> ? AND last_seen_at >= ?", kind, values, walletAddress, since).
+		Distinct().
+		Pluck("wallet_address", &wallets)
+	return wallets
+}
+
+// hasRegularTiming 最近领取奖励的间隔是否过于规律（脚本定时任务特征）
+func (s *RiskService) hasRegularTiming(walletAddress string) bool {
+	var times []time.Time
+	s.db.Model(&models.Reward{}).
+		Where("recipient_wallet = ?", walletAddress).
+		Order("created_at desc").
+		Limit(10).
+		Pluck("created_at", &times)
+	if len(times) < 6 {
+		return false
+	}
+
+	intervals := make([]float64, 0, len(times)-1)
+	var sum float64
+	for i := 1; i < len(times); i++ {
+		d := times[i-1].Sub(times[i]).Seconds()
+		intervals = append(intervals, d)
+		sum += d
+	}
+	mean := sum / float64(len(intervals))
+	if mean <= 0 {
+		return true
+	}
+
+	var variance float64
+	for _, d := range intervals {
+		variance += (d - mean) * (d - mean)
+	}
+	variance /= float64(len(intervals))
+
+	// 变异系数低于5%视为机器行为
+	return math.Sqrt(variance)/mean < 0.05
+}
+
+// hasClusterBurst 同簇钱包是否在短时间内集中领取奖励
+func (s *RiskService) hasClusterBurst(walletAddress string) bool {
+	cluster := append(s.clusterWallets(walletAddress, FingerprintIP), s.clusterWallets(walletAddress, FingerprintDevice)...)
+	if len(cluster) == 0 {
+		return false
+	}
+
+	var count int64
+	s.db.Model(&models.Reward{}).
+		Where("recipient_wallet IN ? AND created_at >= ?", cluster, time.Now().Add(-riskBurstWindow)).
+		Distinct("recipient_wallet").
+		Count(&count)
+	return count >= 2
+}
+
+// scheduleChainRefresh 链上数据过期（每天一次）时在后台刷新，同一钱包同时只有一个刷新任务
+func (s *RiskService) scheduleChainRefresh(profile *models.WalletRiskProfile) {
+	if profile.ChainCheckedAt != nil && time.Since(*profile.ChainCheckedAt) < riskChainInterval {
+		return
+	}
+	if !common.IsHexAddress(profile.WalletAddress) {
+		return
+	}
+	wallet := profile.WalletAddress
+	if _, running := riskChainPending.LoadOrStore(wallet, true); running {
+		return
+	}
+
+	checkAge := profile.AgeCheckedAt == nil
+	go func() {
+		defer riskChainPending.Delete(wallet)
+		s.refreshChainActivity(wallet, checkAge)
+	}()
+}
+
+// chainClient 共用的 BSC 节点连接（首次使用时建立）
+func (s *RiskService) chainClient() (*ethclient.Client, error) {
+	riskChainMu.Lock()
+	defer riskChainMu.Unlock()
+
+	if riskChainClient == nil {
+		client, err := ethclient.Dial(s.cfg.BSCNodeURL)
+		if err != nil {
+			return nil, err
+		}
+		riskChainClient = client
+	}
+	return riskChainClient, nil
+}
+
+// refreshChainActivity 刷新链上交易数和余额，并让评分缓存失效
+func (s *RiskService) refreshChainActivity(walletAddress string, checkAge bool) {
+	client, err := s.chainClient()
+	if err != nil {
+		log.Printf("Risk: failed to connect to BSC node: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addr := common.HexToAddress(walletAddress)
+	nonce, err := client.NonceAt(ctx, addr, nil)
+	if err != nil {
+		log.Printf("Risk: failed to get nonce for %s: %v", walletAddress, err)
+		return
+	}
+	balance, err := client.BalanceAt(ctx, addr, nil)
+	if err != nil {
+		log.Printf("Risk: failed to get balance for %s: %v", walletAddress, err)
+		return
+	}
+
+	err = s.db.Model(&models.WalletRiskProfile{}).
+		Where("wallet_address = ?", walletAddress).
+		Updates(map[string]interface{}{
+			"chain_tx_count":   nonce,
+			"chain_balance":    decimal.NewFromBigInt(balance, -18),
+			"chain_checked_at": time.Now(),
+			"scored_at":        time.Time{},
+		}).Error
+	if err != nil {
+		log.Printf("Risk: failed to save chain activity for %s: %v", walletAddress, err)
+		return
+	}
+
+	// 钱包年龄只查一次
+	if nonce > 0 && checkAge {
+		s.refreshWalletAge(walletAddress)
+	}
+}
+
+// refreshWalletAge 二分查找nonce首次大于0的区块，得到首笔交易时间
+// 需要节点支持历史状态查询（归档节点），不支持时保持未知
+func (s *RiskService) refreshWalletAge(walletAddress string) {
+	now := time.Now()
+	updates := map[string]interface{}{"age_checked_at": now}
+	defer func() {
+		s.db.Model(&models.WalletRiskProfile{}).
+			Where("wallet_address = ?", walletAddress).
+			Updates(updates)
+	}()
+
+	client, err := s.chainClient()
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	addr := common.HexToAddress(walletAddress)
+	lo, hi := uint64(0), header.Number.Uint64()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		nonce, err := client.NonceAt(ctx, addr, new(big.Int).SetUint64(mid))
+		if err != nil {
+			log.Printf("Risk: wallet age lookup unavailable for %s: %v", walletAddress, err)
+			return
+		}
+		if nonce > 0 {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	first, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(lo))
+	if err != nil {
+		return
+	}
+	updates["first_tx_at"] = time.Unix(int64(first.Time), 0)
+	updates["scored_at"] = time.Time{}
+}
+
+// ==================== 指纹记录 ====================
+
+// QueueFingerprint 记录钱包使用的IP/设备（入队后批量写入，不阻塞请求；队列满时丢弃）
+func (s *RiskService) QueueFingerprint(walletAddress string, kind string, value string) {
+	if walletAddress == "" || value == "" {
+		return
+	}
+	fp := models.WalletFingerprint{
+		WalletAddress: strings.ToLower(walletAddress),
+		Kind:          kind,
+		Value:         value,
+		HitCount:      1,
+		LastSeenAt:    time.Now(),
+	}
+	select {
+	case fingerprintQueue <- fp:
+	default:
+	}
+}
+
+// StartFingerprintRecorder 批量写入指纹队列（满一批或每隔 riskFingerprintFlush 写一次）
+func (s *RiskService) StartFingerprintRecorder(ctx context.Context) {
+	ticker := time.NewTicker(riskFingerprintFlush)
+	defer ticker.Stop()
+
+	batch := make([]models.WalletFingerprint, 0, riskFingerprintBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.saveFingerprints(batch); err != nil {
+			log.Printf("Fingerprint recorder: failed to save %d fingerprints: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			log.Println("Fingerprint recorder stopped")
+			return
+		case fp := <-fingerprintQueue:
+			batch = append(batch, fp)
+			if len(batch) >= riskFingerprintBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// saveFingerprints 合并同一批内重复的指纹后一次写入
+func (s *RiskService) saveFingerprints(batch []models.WalletFingerprint) error {
+	type key struct{ wallet, kind, value string }
+	merged := make(map[key]int)
+	rows := make([]models.WalletFingerprint, 0, len(batch))
+	for _, fp := range batch {
+		k := key{fp.WalletAddress, fp.Kind, fp.Value}
+		if i, ok := merged[k]; ok {
+			rows[i].HitCount += fp.HitCount
+			rows[i].LastSeenAt = fp.LastSeenAt
+			continue
+		}
+		merged[k] = len(rows)
+		rows = append(rows, fp)
+	}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "wallet_address"}, {Name: "kind"}, {Name: "value"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"hit_count":    gorm.Expr("wallet_fingerprints.hit_count + EXCLUDED.hit_count"),
+			"last_seen_at": gorm.Expr("EXCLUDED.last_seen_at"),
+			"updated_at":   gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&rows).Error
+}
+
+// ==================== 管理员审核 ====================
+
+// RiskClusterMember 簇内钱包
+type RiskClusterMember struct {
+	WalletAddress   string `json:"walletAddress"`
+	Score           int    `json:"score"`
+	Flagged         bool   `json:"flagged"`
+	Reasons         string `json:"reasons,omitempty"`
+	DeferredRewards int64  `json:"deferredRewards"`
+}
+
+// RiskCluster 共用同一IP/设备的钱包簇
+type RiskCluster struct {
+	Kind        string              `json:"kind"`
+	Value       string              `json:"value"`
+	WalletCount int                 `json:"walletCount"`
+	LastSeenAt  time.Time           `json:"lastSeenAt"`
+	Members     []RiskClusterMember `json:"members"`
+}
+
+// GetClusters 获取共用IP/设备的钱包簇（按钱包数降序）
+func (s *RiskService) GetClusters(kind string, minSize int, limit int) ([]RiskCluster, error) {
+	if minSize < 2 {
+		minSize = 2
+	}
+
+	query := s.db.Model(&models.WalletFingerprint{}).
+		Select("kind, value, COUNT(DISTINCT wallet_address) AS wallet_count, MAX(last_seen_at) AS last_seen_at").
+		Where("last_seen_at >= ?", time.Now().Add(-riskFingerprintTTL))
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var clusters []RiskCluster
+	err := query.Group("kind, value").
+		Having("COUNT(DISTINCT wallet_address) >= ?", minSize).
+		Order("wallet_count DESC, last_seen_at DESC").
+		Limit(limit).
+		Scan(&clusters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range clusters {
+		var wallets []string
+		s.db.Model(&models.WalletFingerprint{}).
+			Where("kind = ? AND value = ?", clusters[i].Kind, clusters[i].Value).
+			Distinct().
+			Pluck("wallet_address", &wallets)
+
+		var profiles []models.WalletRiskProfile
+		s.db.Where("wallet_address IN ?", wallets).Find(&profiles)
+		profileMap := make(map[string]models.WalletRiskProfile, len(profiles))
+		for _, p := range profiles {
+			profileMap[p.WalletAddress] = p
+		}
+
+		members := make([]RiskClusterMember, 0, len(wallets))
+		for _, w := range wallets {
+			member := RiskClusterMember{WalletAddress: w, Score: -1}
+			if p, ok := profileMap[w]; ok {
+				member.Score = p.Score
+				member.Flagged = p.Flagged
+				member.Reasons = p.Reasons
+			}
+			s.db.Model(&models.Reward{}).
+				Where("recipient_wallet = ? AND status = ?", w, RewardStatusDeferred).
+				Count(&member.DeferredRewards)
+			members = append(members, member)
+		}
+		clusters[i].Members = members
+	}
+
+	return clusters, nil
+}
+
+// GetFingerprints 获取钱包的IP/设备记录
+func (s *RiskService) GetFingerprints(walletAddress string) ([]models.WalletFingerprint, error) {
+	var fps []models.WalletFingerprint
+	err := s.db.Where("wallet_address = ?", strings.ToLower(walletAddress)).
+		Order("last_seen_at desc").
+		Find(&fps).Error
+	return fps, err
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// 全文搜索：
+//   - 分词在应用侧完成：英文/数字按词切分并转小写，中日韩文字按二元组（bigram）切分
+//   - 分词结果带位置和权重写入 search_vector（tsvector，GIN 索引），不依赖数据库的分词器和 locale
+//   - search_text 保存可公开检索的原文（pg_trgm 索引），用于单字和子串匹配
+//   - 付费/持仓帖子（scope=all）只索引预览，未解锁的正文不会被搜到
+//   - 排序 = 文本相关度 + SearchHotnessWeight * ln(1 + 热度)
+//   - 帖子/Agent 新建或修改（updated_at 晚于 search_indexed_at）后由后台任务重建索引
+const (
+	searchIndexBatch  = 500
+	searchMaxTokens   = 4000 // 单条记录最多索引的词元数（tsvector 位置上限 16383）
+	searchSnippetLen  = 120  // 摘要长度（字符）
+	searchSnippetLead = 30   // 摘要中命中词前保留的字符数
+)
+
+type SearchService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewSearchService(db *gorm.DB, cfg *config.Config) *SearchService {
+	return &SearchService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// ==================== 分词 ====================
+
+// isCJK 是否为需要按二元组切分的中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// SearchTokens 将文本切分为检索词元（中日韩文字连续片段切为二元组，单字保留）
+func SearchTokens(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// buildTSVector 生成 tsvector 文本（'词元':位置权重），weighted 中的文本权重为 A，其余为默认 D
+func buildTSVector(weighted string, text string) string {
+	var b strings.Builder
+	pos := 0
+	write := func(tokens []string, weight string) {
+		for _, t := range tokens {
+			if pos >= searchMaxTokens {
+				return
+			}
+			pos++
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "'%s':%d%s", t, pos, weight)
+		}
+	}
+	write(SearchTokens(weighted), "A")
+	write(SearchTokens(text), "")
+	return b.String()
+}
+
+// buildTSQuery 生成 tsquery 文本（所有词元需同时命中），无可用词元时返回空
+func buildTSQuery(query string) string {
+	tokens := SearchTokens(query)
+	seen := make(map[string]bool)
+	parts := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		parts = append(parts, "'"+t+"'")
+	}
+	return strings.Join(parts, " & ")
+}
+
+// likePattern 转义 LIKE 通配符
+func likePattern(query string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(query) + "%"
+}
+
+// ==================== 建索引 ====================
+
+// postSearchText 帖子可公开检索的正文（scope=all 的付费/持仓帖子只用预览）
+func postSearchText(post *models.Post) string {
+	if post.GateType != "" && post.GateScope != "media" {
+		return post.Preview
+	}
+	return post.Content
+}
+
+// IndexPost 重建单个帖子的索引
+func (s *SearchService) IndexPost(post *models.Post) error {
+	topics := strings.ReplaceAll(post.Topics, ",", " ")
+	text := postSearchText(post)
+	return s.db.Exec(`UPDATE posts SET search_text = ?, search_vector = CAST(? AS tsvector), search_indexed_at = NOW() WHERE id = ?`,
+		strings.TrimSpace(text+"\n"+topics), buildTSVector(topics, text), post.ID).Error
+}
+
+// IndexAgent 重建单个 Agent 的索引
+func (s *SearchService) IndexAgent(agent *models.Agent) error {
+	return s.db.Exec(`UPDATE agents SET search_vector = CAST(? AS tsvector), search_indexed_at = NOW() WHERE id = ?`,
+		buildTSVector(agent.Username, agent.Bio), agent.ID).Error
+}
+
+// StartSearchIndexer 启动索引任务（每分钟处理新建和修改过的记录）
+func (s *SearchService) StartSearchIndexer(ctx context.Context) {
+	s.indexPending()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Search indexer stopped")
+			return
+		case <-ticker.C:
+			s.indexPending()
+		}
+	}
+}
+
+// indexPending 处理待索引的帖子和 Agent
+func (s *SearchService) indexPending() {
+	for {
+		var posts []models.Post
+		if err := s.db.Where("search_indexed_at IS NULL OR updated_at > search_indexed_at").
+			Limit(searchIndexBatch).Find(&posts).Error; err != nil {
+			log.Printf("Search indexer: failed to load posts: %v", err)
+			return
+		}
+		for i := range posts {
+			if err := s.IndexPost(&posts[i]); err != nil {
+				log.Printf("Search indexer: failed to index post %d: %v", posts[i].ID, err)
+				return
+			}
+		}
+		if len(posts) < searchIndexBatch {
+			break
+		}
+	}
+
+	var agents []models.Agent
+	if err := s.db.Where("search_indexed_at IS NULL OR updated_at > search_indexed_at").Find(&agents).Error; err != nil {
+		log.Printf("Search indexer: failed to load agents: %v", err)
+		return
+	}
+	for i := range agents {
+		if err := s.IndexAgent(&agents[i]); err != nil {
+			log.Printf("Search indexer: failed to index agent %d: %v", agents[i].ID, err)
+			return
+		}
+	}
+}
+
+// ==================== 查询 ====================
+
+// PostSearchInput 帖子搜索条件
+type PostSearchInput struct {
+	Query    string
+	Category string
+	AgentID  uint
+	From     *time.Time
+	To       *time.Time
+	Media    string // image/video/text（无图片视频）/空表示不限
+	Limit    int
+	Offset   int
+}
+
+// searchHit 命中记录及得分
+type searchHit struct {
+	ID    uint
+	Score float64
+}
+
+// SearchPosts 搜索帖子，按相关度与热度混合排序
+func (s *SearchService) SearchPosts(input PostSearchInput) ([]models.Post, int64, error) {
+	tsQuery := buildTSQuery(input.Query)
+	pattern := likePattern(strings.TrimSpace(input.Query))
+
+	hasImage := "EXISTS (SELECT 1 FROM post_images i WHERE i.post_id = posts.id AND i.deleted_at IS NULL)"
+	hasVideo := "EXISTS (SELECT 1 FROM post_videos v WHERE v.post_id = posts.id AND v.deleted_at IS NULL)"
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Table("posts").Where("posts.deleted_at IS NULL")
+		if tsQuery != "" {
+			db = db.Where("(posts.search_vector @@ CAST(? AS tsquery) OR posts.search_text ILIKE ?)", tsQuery, pattern)
+		} else {
+			db = db.Where("posts.search_text ILIKE ?", pattern)
+		}
+		if input.Category != "" && input.Category != "all" {
+			db = db.Where("posts.category = ?", input.Category)
+		}
+		if input.AgentID > 0 {
+			db = db.Where("posts.agent_id = ?", input.AgentID)
+		}
+		if input.From != nil {
+			db = db.Where("posts.posted_at >= ?", *input.From)
+		}
+		if input.To != nil {
+			db = db.Where("posts.posted_at < ?", *input.To)
+		}
+		switch input.Media {
+		case "image":
+			db = db.Where(hasImage)
+		case "video":
+			db = db.Where(hasVideo)
+		case "text":
+			db = db.Where("NOT " + hasImage + " AND NOT " + hasVideo)
+		}
+		return db
+	}
+
+	var total int64
+	if err := s.db.Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	relevance := "word_similarity(?, posts.search_text)"
+	args := []interface{}{input.Query}
+	if tsQuery != "" {
+		relevance = "ts_rank_cd(posts.search_vector, CAST(? AS tsquery), 32) + " + relevance
+		args = append([]interface{}{tsQuery}, args...)
+	}
+	args = append(args, s.cfg.SearchHotnessWeight)
+
+	var hits []searchHit
+	if err := s.db.Scopes(filter).Select("posts.id, "+relevance+" + ? * LN(1 + GREATEST(posts.hotness_score, 0)) AS score", args...).
+		Order("score DESC, posts.id DESC").
+		Limit(input.Limit).Offset(input.Offset).
+		Scan(&hits).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(hits) == 0 {
+		return []models.Post{}, total, nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var found []models.Post
+	if err := s.db.Preload("Agent").Preload("Images").Preload("Videos").
+		Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[uint]models.Post, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	posts := make([]models.Post, 0, len(hits))
+	for _, hit := range hits {
+		if p, ok := byID[hit.ID]; ok {
+			p.SearchScore = hit.Score
+			posts = append(posts, p)
+		}
+	}
+	return posts, total, nil
+}
+
+// SearchAgents 搜索已审核的 Agent，按相关度与获赞数混合排序
+func (s *SearchService) SearchAgents(query string, limit int, offset int) ([]models.Agent, int64, error) {
+	tsQuery := buildTSQuery(query)
+	pattern := likePattern(strings.TrimSpace(query))
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Model(&models.Agent{}).Where("is_approved = ?", true)
+		if tsQuery != "" {
+			return db.Where("(search_vector @@ CAST(? AS tsquery) OR username ILIKE ?)", tsQuery, pattern)
+		}
+		return db.Where("username ILIKE ?", pattern)
+	}
+
+	var total int64
+	if err := s.db.Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	relevance := "similarity(username, ?)"
+	args := []interface{}{query}
+	if tsQuery != "" {
+		relevance = "ts_rank_cd(search_vector, CAST(? AS tsquery), 32) + " + relevance
+		args = append([]interface{}{tsQuery}, args...)
+	}
+	args = append(args, s.cfg.SearchHotnessWeight)
+
+	var agents []models.Agent
+	err := s.db.Scopes(filter).
+		Order(gorm.Expr(relevance+" + ? * LN(1 + GREATEST(likes_received, 0)) DESC, id DESC", args...)).
+		Limit(limit).Offset(offset).
+		Find(&agents).Error
+	return agents, total, err
+}
+
+// ==================== 摘要 ====================
+
+// Snippet 截取包含命中词的摘要，命中部分用 <mark> 标记（其余内容已做 HTML 转义）
+func Snippet(text string, query string) string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return ""
+	}
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, token := range SearchTokens(query) {
+		term := []rune(token)
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if string(lower[i:i+len(term)]) != token {
+				continue
+			}
+			for j := i; j < i+len(term); j++ {
+				marked[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start := 0
+	if first > searchSnippetLead {
+		start = first - searchSnippetLead
+	}
+	end := start + searchSnippetLen
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + segment + "</mark>")
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Agent 质押分红：
+//   - 质押从可用余额转入 StakedBalance，每次质押是一笔独立仓位
+//   - 周期按 UTC 对齐（StakeEpochHours），周期结束后把该 Agent 在周期内打赏产生的 tip_fee 按 StakeFeeShare 分给质押者
+//   - 只有周期开始前已质押、且周期内未申请解除的仓位参与分红，按仓位金额比例分配，分红直接进入可用余额
+//   - 解除质押后经过 StakeUnbondHours 冷却才回到可用余额，冷却期间不分红
+//   - 分出的金额记为负的 stake_payout 平台收入，保证平台收入合计准确
+type StakingService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewStakingService(db *gorm.DB, cfg *config.Config) *StakingService {
+	return &StakingService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// AgentStakingInfo Agent 的质押概况
+type AgentStakingInfo struct {
+	AgentID      uint                `json:"agentId"`
+	TotalStaked  decimal.Decimal     `json:"totalStaked"`
+	StakerCount  int64               `json:"stakerCount"`
+	ShareRate    decimal.Decimal     `json:"shareRate"`
+	EpochHours   int                 `json:"epochHours"`
+	NextEpochAt  time.Time           `json:"nextEpochAt"`
+	RecentEpochs []models.StakeEpoch `json:"recentEpochs"`
+}
+
+// Stake 质押到 Agent
+func (s *StakingService) Stake(walletAddress string, agentID uint, amount decimal.Decimal) (*models.AgentStake, error) {
+	walletAddress = strings.ToLower(walletAddress)
+	if amount.LessThan(decimal.NewFromFloat(s.cfg.MinStakeAmount)) {
+		return nil, fmt.Errorf("minimum stake amount is %s", decimal.NewFromFloat(s.cfg.MinStakeAmount).String())
+	}
+
+	var agent models.Agent
+	if err := s.db.First(&agent, agentID).Error; err != nil {
+		return nil, errors.New("agent not found")
+	}
+	if !agent.IsApproved {
+		return nil, errors.New("agent is not approved")
+	}
+
+	stake := &models.AgentStake{
+		WalletAddress: walletAddress,
+		AgentID:       agentID,
+		Amount:        amount,
+		Earned:        decimal.Zero,
+		Status:        "active",
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新，防止并发超额质押
+		result := tx.Model(&models.TokenBalance{}).
+			Where("wallet_address = ? AND balance >= ?", walletAddress, amount).
+			UpdateColumns(map[string]interface{}{
+				"balance":        gorm.Expr("balance - ?", amount),
+				"staked_balance": gorm.Expr("staked_balance + ?", amount),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("insufficient balance")
+		}
+		return tx.Create(stake).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stake, nil
+}
+
+// Unstake 申请解除质押（进入冷却期）
+func (s *StakingService) Unstake(walletAddress string, stakeID uint) (*models.AgentStake, error) {
+	var stake models.AgentStake
+	if err := s.db.Where("id = ? AND wallet_address = ?", stakeID, strings.ToLower(walletAddress)).First(&stake).Error; err != nil {
+		return nil, errors.New("stake not found")
+	}
+	if stake.Status != "active" {
+		return nil, errors.New("stake is not active")
+	}
+
+	now := time.Now()
+	availableAt := now.Add(time.Duration(s.cfg.StakeUnbondHours) * time.Hour)
+	result := s.db.Model(&stake).Where("status = ?", "active").Updates(map[string]interface{}{
+		"status":       "unbonding",
+		"unbond_at":    now,
+		"available_at": availableAt,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("stake is not active")
+	}
+
+	// 冷却期为0时立即到账
+	if !availableAt.After(now) {
+		s.ReleaseUnbonded(stake.WalletAddress)
+	}
+	s.db.First(&stake, stake.ID)
+	return &stake, nil
+}
+
+// ReleaseUnbonded 将冷却结束的仓位退回可用余额（walletAddress 为空时处理全部）
+func (s *StakingService) ReleaseUnbonded(walletAddress string) error {
+	var stakes []models.AgentStake
+	query := s.db.Where("status = ? AND available_at <= ?", "unbonding", time.Now())
+	if walletAddress != "" {
+		query = query.Where("wallet_address = ?", strings.ToLower(walletAddress))
+	}
+	if err := query.Find(&stakes).Error; err != nil {
+		return err
+	}
+
+	for _, stake := range stakes {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.AgentStake{}).
+				Where("id = ? AND status = ?", stake.ID, "unbonding").
+				Updates(map[string]interface{}{"status": "released", "released_at": time.Now()})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			return tx.Model(&models.TokenBalance{}).
+				Where("wallet_address = ?", stake.WalletAddress).
+				UpdateColumns(map[string]interface{}{
+					"balance":        gorm.Expr("balance + ?", stake.Amount),
+					"staked_balance": gorm.Expr("staked_balance - ?", stake.Amount),
+				}).Error
+		})
+		if err != nil {
+			log.Printf("Failed to release stake %d: %v", stake.ID, err)
+		}
+	}
+	return nil
+}
+
+// ListStakes 获取用户的质押仓位（不含已退回的）
+func (s *StakingService) ListStakes(walletAddress string) ([]models.AgentStake, error) {
+	var stakes []models.AgentStake
+	err := s.db.Where("wallet_address = ? AND status <> ?", strings.ToLower(walletAddress), "released").
+		Order("created_at desc").Find(&stakes).Error
+	return stakes, err
+}
+
+// GetRewards 获取用户的分红记录
+func (s *StakingService) GetRewards(walletAddress string, limit int, offset int) ([]models.StakeReward, int64, error) {
+	var rewards []models.StakeReward
+	var total int64
+
+	query := s.db.Model(&models.StakeReward{}).Where("wallet_address = ?", strings.ToLower(walletAddress))
+	query.Count(&total)
+
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&rewards).Error
+	return rewards, total, err
+}
+
+// GetAgentStaking 获取 Agent 的质押概况
+func (s *StakingService) GetAgentStaking(agentID uint) (*AgentStakingInfo, error) {
+	info := &AgentStakingInfo{
+		AgentID:     agentID,
+		ShareRate:   decimal.NewFromFloat(s.cfg.StakeFeeShare),
+		EpochHours:  s.cfg.StakeEpochHours,
+		NextEpochAt: s.epochStart(time.Now()).Add(s.epochLength()),
+	}
+
+	var row struct {
+		Total   decimal.Decimal
+		Stakers int64
+	}
+	if err := s.db.Model(&models.AgentStake{}).
+		Select("COALESCE(SUM(amount), 0) AS total, COUNT(DISTINCT wallet_address) AS stakers").
+		Where("agent_id = ? AND status = ?", agentID, "active").
+		Scan(&row).Error; err != nil {
+		return nil, err
+	}
+	info.TotalStaked = row.Total
+	info.StakerCount = row.Stakers
+
+	if err := s.db.Where("agent_id = ?", agentID).Order("epoch_start desc").Limit(10).
+		Find(&info.RecentEpochs).Error; err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ==================== 周期分红 ====================
+
+// StartStakingDistributor 定期分红和释放冷却结束的仓位（在单独goroutine中运行）
+func (s *StakingService) StartStakingDistributor(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Staking distributor stopped")
+			return
+		case <-ticker.C:
+			if err := s.ReleaseUnbonded(""); err != nil {
+				log.Printf("Failed to release unbonded stakes: %v", err)
+			}
+			if err := s.DistributeEpochs(); err != nil {
+				log.Printf("Failed to distribute staking epochs: %v", err)
+			}
+		}
+	}
+}
+
+// DistributeEpochs 为每个有质押的 Agent 补齐已结束但未分红的周期
+func (s *StakingService) DistributeEpochs() error {
+	length := s.epochLength()
+	current := s.epochStart(time.Now())
+
+	var agentIDs []uint
+	if err := s.db.Model(&models.AgentStake{}).Distinct("agent_id").Pluck("agent_id", &agentIDs).Error; err != nil {
+		return err
+	}
+
+	for _, agentID := range agentIDs {
+		var start time.Time
+		var last models.StakeEpoch
+		if err := s.db.Where("agent_id = ?", agentID).Order("epoch_start desc").First(&last).Error; err == nil {
+			start = last.EpochStart.Add(length)
+		} else {
+			// 第一个可能有分红的周期：最早仓位之后的下一个周期
+			var first models.AgentStake
+			if err := s.db.Where("agent_id = ?", agentID).Order("created_at asc").First(&first).Error; err != nil {
+				continue
+			}
+			start = s.epochStart(first.CreatedAt).Add(length)
+		}
+
+		// 每次最多补 30 个周期
+		for n := 0; start.Before(current) && n < 30; n++ {
+			if err := s.distributeEpoch(agentID, start, start.Add(length)); err != nil {
+				log.Printf("Failed to distribute epoch %s for agent %d: %v", start.Format(time.RFC3339), agentID, err)
+				break
+			}
+			start = start.Add(length)
+		}
+	}
+	return nil
+}
+
+// distributeEpoch 分配一个周期
+func (s *StakingService) distributeEpoch(agentID uint, start time.Time, end time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		epoch := models.StakeEpoch{
+			AgentID:    agentID,
+			EpochStart: start,
+			EpochEnd:   end,
+			ShareRate:  decimal.NewFromFloat(s.cfg.StakeFeeShare),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&epoch)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil // 已分配
+		}
+
+		// 周期内该 Agent 打赏产生的平台抽成
+		if err := tx.Raw(`SELECT COALESCE(SUM(pi.amount), 0) FROM platform_incomes pi
+			JOIN token_tips t ON t.id = pi.reference_id
+			WHERE pi.deleted_at IS NULL AND pi.income_type = 'tip_fee' AND pi.reference_type = 'tip'
+			AND t.to_agent_id = ? AND pi.created_at >= ? AND pi.created_at < ?`,
+			agentID, start, end).Scan(&epoch.FeeIncome).Error; err != nil {
+			return err
+		}
+
+		// 周期开始前已质押、周期结束前未申请解除的仓位
+		var stakes []models.AgentStake
+		if err := tx.Where("agent_id = ? AND created_at < ? AND (unbond_at IS NULL OR unbond_at >= ?)", agentID, start, end).
+			Find(&stakes).Error; err != nil {
+			return err
+		}
+		wallets := make(map[string]bool)
+		for _, stake := range stakes {
+			epoch.TotalStaked = epoch.TotalStaked.Add(stake.Amount)
+			wallets[stake.WalletAddress] = true
+		}
+		epoch.StakerCount = len(wallets)
+
+		pool := epoch.FeeIncome.Mul(epoch.ShareRate).Truncate(18)
+		if pool.IsPositive() && epoch.TotalStaked.IsPositive() {
+			for _, stake := range stakes {
+				amount := pool.Mul(stake.Amount).Div(epoch.TotalStaked).Truncate(18)
+				if !amount.IsPositive() {
+					continue
+				}
+				if err := tx.Create(&models.StakeReward{
+					EpochID:       epoch.ID,
+					StakeID:       stake.ID,
+					AgentID:       agentID,
+					WalletAddress: stake.WalletAddress,
+					StakeAmount:   stake.Amount,
+					Amount:        amount,
+				}).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&models.AgentStake{}).Where("id = ?", stake.ID).
+					UpdateColumn("earned", gorm.Expr("earned + ?", amount)).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&models.TokenBalance{}).Where("wallet_address = ?", stake.WalletAddress).
+					UpdateColumn("balance", gorm.Expr("balance + ?", amount)).Error; err != nil {
+					return err
+				}
+				epoch.Distributed = epoch.Distributed.Add(amount)
+			}
+		}
+
+		if epoch.Distributed.IsPositive() {
+			if err := tx.Create(&models.PlatformIncome{
+				IncomeType:    "stake_payout",
+				Amount:        epoch.Distributed.Neg(),
+				ReferenceType: "stake_epoch",
+				ReferenceID:   epoch.ID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&epoch).Error
+	})
+}
+
+// epochLength 周期长度
+func (s *StakingService) epochLength() time.Duration {
+	hours := s.cfg.StakeEpochHours
+	if hours <= 0 {
+		hours = 24
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// epochStart t 所在周期的开始时间（UTC 对齐）
+func (s *StakingService) epochStart(t time.Time) time.Time {
+	return t.UTC().Truncate(s.epochLength())
+}
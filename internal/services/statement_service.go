@@ -0,0 +1,145 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 账单规则：
+//   - 余额口径 = 可用余额 + 锁定余额 + 未解锁奖励 + 质押中 + 悬赏托管（与储备金证明一致，质押/托管和退回不产生明细）
+//   - 悬赏结算和付费解锁与打赏相同，拆成 Agent 实收和平台抽成两行
+//   - 提现在申请时扣款（失败退回的不计入），到账金额和手续费分两行
+//   - 打赏拆成打赏金额（对方实收）和平台抽成两行
+//   - 只计入已发放的奖励（延迟审核/拒绝的不计入）
+//   - 逐行从数据库游标读取，不一次性加载全部记录
+type StatementService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewStatementService(db *gorm.DB, cfg *config.Config) *StatementService {
+	return &StatementService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// StatementEntry 账单明细
+type StatementEntry struct {
+	Time         time.Time       `json:"time"`
+	Type         string          `json:"type"`   // deposit/withdrawal/withdrawal_fee/tip_sent/tip_fee/tip_received/reward/stake_reward/bounty_paid/bounty_fee/bounty_received/unlock_paid/unlock_fee/unlock_received
+	Amount       decimal.Decimal `json:"amount"` // 正数入账，负数出账
+	Balance      decimal.Decimal `json:"balance"`
+	Reference    string          `json:"reference,omitempty"`    // 交易哈希 / 帖子ID / 奖励类型
+	Counterparty string          `json:"counterparty,omitempty"` // 对方（钱包/Agent）
+	SourceID     uint            `json:"sourceId"`               // 原始记录ID
+}
+
+// statementSource 账单数据来源（一段 SELECT，列：entry_at, type, amount, reference, counterparty, source_id）
+type statementSource struct {
+	sql  string
+	args []interface{}
+}
+
+// OpeningBalance 期初余额（from 之前所有明细之和）
+func (s *StatementService) OpeningBalance(accountType string, accountID uint, walletAddress string, from time.Time) (decimal.Decimal, error) {
+	union, args := s.unionSQL(accountType, accountID, walletAddress)
+
+	var opening decimal.Decimal
+	err := s.db.Raw("SELECT COALESCE(SUM(amount), 0) FROM ("+union+") t WHERE entry_at < ?", append(args, from)...).
+		Row().Scan(&opening)
+	return opening, err
+}
+
+// Stream 按时间顺序逐条输出 [from, to) 的明细，并计算滚动余额
+func (s *StatementService) Stream(accountType string, accountID uint, walletAddress string, from time.Time, to time.Time, opening decimal.Decimal, fn func(StatementEntry) error) (decimal.Decimal, error) {
+	union, args := s.unionSQL(accountType, accountID, walletAddress)
+
+	rows, err := s.db.Raw("SELECT entry_at, type, amount, reference, counterparty, source_id FROM ("+union+") t WHERE entry_at >= ? AND entry_at < ? ORDER BY entry_at ASC, type ASC, source_id ASC",
+		append(args, from, to)...).Rows()
+	if err != nil {
+		return opening, err
+	}
+	defer rows.Close()
+
+	balance := opening
+	for rows.Next() {
+		var entry StatementEntry
+		if err := rows.Scan(&entry.Time, &entry.Type, &entry.Amount, &entry.Reference, &entry.Counterparty, &entry.SourceID); err != nil {
+			return balance, err
+		}
+		balance = balance.Add(entry.Amount)
+		entry.Balance = balance
+		if err := fn(entry); err != nil {
+			return balance, err
+		}
+	}
+	return balance, rows.Err()
+}
+
+// unionSQL 合并各来源
+func (s *StatementService) unionSQL(accountType string, accountID uint, walletAddress string) (string, []interface{}) {
+	sources := s.sources(accountType, accountID, strings.ToLower(walletAddress))
+
+	parts := make([]string, 0, len(sources))
+	var args []interface{}
+	for _, src := range sources {
+		parts = append(parts, src.sql)
+		args = append(args, src.args...)
+	}
+	return strings.Join(parts, " UNION ALL "), args
+}
+
+// sources 账户涉及的所有资金流水
+func (s *StatementService) sources(accountType string, accountID uint, walletAddress string) []statementSource {
+	if accountType == "agent" {
+		return []statementSource{
+			{`SELECT created_at AS entry_at, 'withdrawal' AS type, -net_amount AS amount, COALESCE(tx_hash, '') AS reference, wallet_address AS counterparty, id AS source_id
+				FROM withdrawals WHERE deleted_at IS NULL AND user_type = 'agent' AND user_id = ? AND status <> 'failed'`, []interface{}{accountID}},
+			{`SELECT created_at, 'withdrawal_fee', -fee, COALESCE(tx_hash, ''), '', id
+				FROM withdrawals WHERE deleted_at IS NULL AND user_type = 'agent' AND user_id = ? AND status <> 'failed' AND fee > 0`, []interface{}{accountID}},
+			{`SELECT created_at, 'tip_received', agent_received, 'post:' || CAST(post_id AS TEXT),
+				CASE WHEN from_type = 'agent' THEN 'agent:' || CAST(from_agent_id AS TEXT) ELSE from_wallet END, id
+				FROM token_tips WHERE deleted_at IS NULL AND to_agent_id = ?`, []interface{}{accountID}},
+			{`SELECT created_at, 'tip_sent', -agent_received, 'post:' || CAST(post_id AS TEXT), 'agent:' || CAST(to_agent_id AS TEXT), id
+				FROM token_tips WHERE deleted_at IS NULL AND from_type = 'agent' AND from_agent_id = ?`, []interface{}{accountID}},
+			{`SELECT created_at, 'tip_fee', -platform_fee, 'post:' || CAST(post_id AS TEXT), '', id
+				FROM token_tips WHERE deleted_at IS NULL AND from_type = 'agent' AND from_agent_id = ? AND platform_fee > 0`, []interface{}{accountID}},
+			{`SELECT created_at, 'reward', amount, reward_type, '', id
+				FROM rewards WHERE deleted_at IS NULL AND recipient_type = 'agent' AND recipient_id = ? AND status = 'granted'`, []interface{}{accountID}},
+			{`SELECT a.paid_at, 'bounty_received', a.agent_received, 'bounty:' || CAST(a.bounty_id AS TEXT), b.creator_wallet, a.id
+				FROM bounty_answers a JOIN bounties b ON b.id = a.bounty_id WHERE a.deleted_at IS NULL AND a.agent_id = ? AND a.paid_at IS NOT NULL`, []interface{}{accountID}},
+			{`SELECT created_at, 'unlock_received', agent_received, 'post:' || CAST(post_id AS TEXT), wallet_address, id
+				FROM post_unlocks WHERE deleted_at IS NULL AND agent_id = ?`, []interface{}{accountID}},
+		}
+	}
+
+	return []statementSource{
+		{`SELECT COALESCE(confirmed_at, created_at) AS entry_at, 'deposit' AS type, amount AS amount, tx_hash AS reference, deposit_address AS counterparty, id AS source_id
+			FROM deposits WHERE deleted_at IS NULL AND wallet_address = ? AND status = 'confirmed'`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'withdrawal', -net_amount, COALESCE(tx_hash, ''), wallet_address, id
+			FROM withdrawals WHERE deleted_at IS NULL AND user_type = 'user' AND wallet_address = ? AND status <> 'failed'`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'withdrawal_fee', -fee, COALESCE(tx_hash, ''), '', id
+			FROM withdrawals WHERE deleted_at IS NULL AND user_type = 'user' AND wallet_address = ? AND status <> 'failed' AND fee > 0`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'tip_sent', -agent_received, 'post:' || CAST(post_id AS TEXT), 'agent:' || CAST(to_agent_id AS TEXT), id
+			FROM token_tips WHERE deleted_at IS NULL AND from_wallet = ?`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'tip_fee', -platform_fee, 'post:' || CAST(post_id AS TEXT), '', id
+			FROM token_tips WHERE deleted_at IS NULL AND from_wallet = ? AND platform_fee > 0`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'reward', amount, reward_type, '', id
+			FROM rewards WHERE deleted_at IS NULL AND recipient_type = 'user' AND recipient_wallet = ? AND status = 'granted'`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'stake_reward', amount, 'agent:' || CAST(agent_id AS TEXT), '', id
+			FROM stake_rewards WHERE deleted_at IS NULL AND wallet_address = ?`, []interface{}{walletAddress}},
+		{`SELECT a.paid_at, 'bounty_paid', -a.agent_received, 'bounty:' || CAST(a.bounty_id AS TEXT), 'agent:' || CAST(a.agent_id AS TEXT), a.id
+			FROM bounty_answers a JOIN bounties b ON b.id = a.bounty_id WHERE a.deleted_at IS NULL AND b.creator_wallet = ? AND a.paid_at IS NOT NULL`, []interface{}{walletAddress}},
+		{`SELECT a.paid_at, 'bounty_fee', -a.platform_fee, 'bounty:' || CAST(a.bounty_id AS TEXT), '', a.id
+			FROM bounty_answers a JOIN bounties b ON b.id = a.bounty_id WHERE a.deleted_at IS NULL AND b.creator_wallet = ? AND a.paid_at IS NOT NULL AND a.platform_fee > 0`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'unlock_paid', -agent_received, 'post:' || CAST(post_id AS TEXT), 'agent:' || CAST(agent_id AS TEXT), id
+			FROM post_unlocks WHERE deleted_at IS NULL AND wallet_address = ?`, []interface{}{walletAddress}},
+		{`SELECT created_at, 'unlock_fee', -platform_fee, 'post:' || CAST(post_id AS TEXT), '', id
+			FROM post_unlocks WHERE deleted_at IS NULL AND wallet_address = ? AND platform_fee > 0`, []interface{}{walletAddress}},
+	}
+}
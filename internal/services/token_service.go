@@ -251,75 +251,251 @@ func (s *TokenService) TipAgent(fromWallet string, agentID uint, postID uint, am
 	var tip *models.TokenTip
 	
 	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// 检查用户余额
-		var userBalance models.TokenBalance
-		err := tx.Where("wallet_address = ?", strings.ToLower(fromWallet)).First(&userBalance).Error
+		// 扣除用户余额（未解锁奖励也可用于打赏）
+		userBalance, err := spendUserTokens(tx, s.cfg, fromWallet, amount)
 		if err != nil {
-			return errors.New("user balance not found")
+			return err
 		}
 		
-		if userBalance.Balance.LessThan(amount) {
-			return errors.New("insufficient balance")
+		// 计算平台抽成（分档/Agent专属费率/质押折扣）并增加Agent余额
+		quote, err := payAgent(tx, s.cfg, FeeInput{
+			FeeType:     FeeTypeTip,
+			PayerType:   "user",
+			PayerWallet: userBalance.WalletAddress,
+			AgentID:     agentID,
+			Amount:      amount,
+		})
+		if err != nil {
+			return err
 		}
 		
-		// 计算平台抽成
-		feeRate := decimal.NewFromFloat(s.cfg.TipFeeRate)
-		platformFee := amount.Mul(feeRate).Round(18)
-		agentReceived := amount.Sub(platformFee)
+		// 创建打赏记录
+		tip = &models.TokenTip{
+			FromWallet:    strings.ToLower(fromWallet),
+			ToAgentID:     agentID,
+			PostID:        postID,
+			Amount:        amount,
+			PlatformFee:   quote.Fee,
+			AgentReceived: quote.NetAmount,
+		}
+		if err := tx.Create(tip).Error; err != nil {
+			return err
+		}
 		
-		// 扣除用户余额
-		userBalance.Balance = userBalance.Balance.Sub(amount)
-		userBalance.TotalTipped = userBalance.TotalTipped.Add(amount)
-		if err := tx.Save(&userBalance).Error; err != nil {
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+			UpdateColumn("tips_amount", gorm.Expr("tips_amount + ?", amount)).Error; err != nil {
 			return err
 		}
 		
-		// 增加Agent余额
-		var agentBalance models.AgentTokenBalance
-		err = tx.Where("agent_id = ?", agentID).First(&agentBalance).Error
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			agentBalance = models.AgentTokenBalance{
-				AgentID: agentID,
-				Balance: decimal.Zero,
-			}
-		} else if err != nil {
+		// 记录平台收入
+		return recordPlatformIncome(tx, "tip_fee", quote.Fee, "tip", tip.ID)
+	})
+	
+	return tip, err
+}
+
+// AgentTipAgent Agent打赏其他Agent的帖子（费率、平台收入与用户打赏一致）
+func (s *TokenService) AgentTipAgent(fromAgentID uint, toAgentID uint, postID uint, amount decimal.Decimal) (*models.TokenTip, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, errors.New("tip amount must be positive")
+	}
+	if fromAgentID == toAgentID {
+		return nil, errors.New("cannot tip your own post")
+	}
+	
+	// 双方都必须已被认领（未认领的无法判断主人），且同一主人名下的 Agent 之间不能互相打赏
+	var agents []models.Agent
+	if err := s.db.Where("id IN ?", []uint{fromAgentID, toAgentID}).Find(&agents).Error; err != nil {
+		return nil, err
+	}
+	if len(agents) != 2 {
+		return nil, errors.New("agent not found")
+	}
+	if agents[0].OwnerUserID == nil || agents[1].OwnerUserID == nil {
+		return nil, errors.New("only claimed agents can tip or be tipped by agents")
+	}
+	if *agents[0].OwnerUserID == *agents[1].OwnerUserID {
+		return nil, errors.New("cannot tip an agent with the same owner")
+	}
+	
+	var tip *models.TokenTip
+	
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		circular, err := s.isCircularTip(tx, fromAgentID, toAgentID)
+		if err != nil {
 			return err
 		}
+		if circular {
+			return errors.New("circular tipping between agents is not allowed")
+		}
 		
-		agentBalance.Balance = agentBalance.Balance.Add(agentReceived)
-		agentBalance.TotalReceived = agentBalance.TotalReceived.Add(agentReceived)
-		if err := tx.Save(&agentBalance).Error; err != nil {
+		if err := spendAgentTokens(tx, s.cfg, fromAgentID, amount); err != nil {
 			return err
 		}
 		
-		// 记录平台收入
-		if platformFee.GreaterThan(decimal.Zero) {
-			income := models.PlatformIncome{
-				IncomeType:    "tip_fee",
-				Amount:        platformFee,
-				ReferenceType: "tip",
-			}
-			if err := tx.Create(&income).Error; err != nil {
-				return err
-			}
+		quote, err := payAgent(tx, s.cfg, FeeInput{
+			FeeType:   FeeTypeTip,
+			PayerType: "agent",
+			PayerID:   fromAgentID,
+			AgentID:   toAgentID,
+			Amount:    amount,
+		})
+		if err != nil {
+			return err
 		}
 		
-		// 创建打赏记录
 		tip = &models.TokenTip{
-			FromWallet:    strings.ToLower(fromWallet),
-			ToAgentID:     agentID,
+			FromType:      "agent",
+			FromAgentID:   &fromAgentID,
+			ToAgentID:     toAgentID,
 			PostID:        postID,
 			Amount:        amount,
-			PlatformFee:   platformFee,
-			AgentReceived: agentReceived,
+			PlatformFee:   quote.Fee,
+			AgentReceived: quote.NetAmount,
+		}
+		if err := tx.Create(tip).Error; err != nil {
+			return err
 		}
 		
-		return tx.Create(tip).Error
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+			UpdateColumn("tips_amount", gorm.Expr("tips_amount + ?", amount)).Error; err != nil {
+			return err
+		}
+		
+		// 与用户打赏同为 tip_fee，参与质押分红
+		return recordPlatformIncome(tx, "tip_fee", quote.Fee, "tip", tip.ID)
 	})
 	
 	return tip, err
 }
 
+// isCircularTip 检查窗口期内是否已存在 to→…→from 的 Agent 打赏链路（加上本次即成环）
+func (s *TokenService) isCircularTip(tx *gorm.DB, fromAgentID uint, toAgentID uint) (bool, error) {
+	maxPath := s.cfg.AgentTipCycleDepth - 1
+	if maxPath < 1 {
+		return false, nil
+	}
+	since := time.Now().Add(-time.Duration(s.cfg.AgentTipWindowHours) * time.Hour)
+	
+	var count int64
+	err := tx.Raw(`WITH RECURSIVE chain(agent_id, depth) AS (
+			SELECT to_agent_id, 1 FROM token_tips
+			WHERE deleted_at IS NULL AND from_type = 'agent' AND from_agent_id = ? AND created_at >= ?
+			UNION
+			SELECT t.to_agent_id, c.depth + 1 FROM token_tips t JOIN chain c ON t.from_agent_id = c.agent_id
+			WHERE t.deleted_at IS NULL AND t.from_type = 'agent' AND t.created_at >= ? AND c.depth < ?
+		)
+		SELECT COUNT(*) FROM chain WHERE agent_id = ?`,
+		toAgentID, since, since, maxPath, fromAgentID).Scan(&count).Error
+	return count > 0, err
+}
+
+// spendAgentTokens Agent打赏支出：优先扣除未解锁余额，保留可提现余额
+func spendAgentTokens(tx *gorm.DB, cfg *config.Config, agentID uint, amount decimal.Decimal) error {
+	return debitAgentTokens(tx, cfg, agentID, amount, "total_tipped", amount)
+}
+
+// debitAgentTokens 扣减 Agent 余额（优先扣除未解锁余额），同时把 counter 列调整 counterDelta
+// 条件更新扣款，并发扣款时余额不足的一方失败
+func debitAgentTokens(tx *gorm.DB, cfg *config.Config, agentID uint, amount decimal.Decimal, counter string, counterDelta decimal.Decimal) error {
+	var agentBalance models.AgentTokenBalance
+	if err := tx.Where("agent_id = ?", agentID).First(&agentBalance).Error; err != nil {
+		return errors.New("agent balance not found")
+	}
+	
+	if agentBalance.Balance.Add(agentBalance.VestingBalance).LessThan(amount) {
+		return errors.New("insufficient balance")
+	}
+	
+	fromVesting := decimal.Min(agentBalance.VestingBalance, amount)
+	fromBalance := amount.Sub(fromVesting)
+	result := tx.Model(&models.AgentTokenBalance{}).
+		Where("agent_id = ? AND vesting_balance >= ? AND balance >= ?", agentID, fromVesting, fromBalance).
+		UpdateColumns(map[string]interface{}{
+			"vesting_balance": gorm.Expr("vesting_balance - ?", fromVesting),
+			"balance":         gorm.Expr("balance - ?", fromBalance),
+			counter:           gorm.Expr(counter+" + ?", counterDelta),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("insufficient balance")
+	}
+	
+	if fromVesting.IsPositive() {
+		return NewVestingService(tx, cfg).SpendVesting(tx, "agent", agentID, "", fromVesting)
+	}
+	return nil
+}
+
+// spendUserTokens 用户打赏类支出：优先扣除未解锁余额，保留可提现余额（打赏和付费解锁共用）
+func spendUserTokens(tx *gorm.DB, cfg *config.Config, walletAddress string, amount decimal.Decimal) (*models.TokenBalance, error) {
+	var userBalance models.TokenBalance
+	if err := tx.Where("wallet_address = ?", strings.ToLower(walletAddress)).First(&userBalance).Error; err != nil {
+		return nil, errors.New("user balance not found")
+	}
+	
+	if userBalance.Balance.Add(userBalance.VestingBalance).LessThan(amount) {
+		return nil, errors.New("insufficient balance")
+	}
+	
+	fromVesting := decimal.Min(userBalance.VestingBalance, amount)
+	if fromVesting.IsPositive() {
+		if err := NewVestingService(tx, cfg).SpendVesting(tx, "user", 0, userBalance.WalletAddress, fromVesting); err != nil {
+			return nil, err
+		}
+		userBalance.VestingBalance = userBalance.VestingBalance.Sub(fromVesting)
+	}
+	
+	userBalance.Balance = userBalance.Balance.Sub(amount.Sub(fromVesting))
+	userBalance.TotalTipped = userBalance.TotalTipped.Add(amount)
+	if err := tx.Save(&userBalance).Error; err != nil {
+		return nil, err
+	}
+	return &userBalance, nil
+}
+
+// payAgent 按打赏费率向 Agent 付款：计算手续费并增加 Agent 余额（打赏和悬赏结算共用）
+// 付款方的扣款由调用方负责
+func payAgent(tx *gorm.DB, cfg *config.Config, input FeeInput) (*FeeQuote, error) {
+	quote, err := NewFeeService(tx, cfg).Quote(tx, input)
+	if err != nil {
+		return nil, err
+	}
+	
+	var agentBalance models.AgentTokenBalance
+	err = tx.Where("agent_id = ?", input.AgentID).First(&agentBalance).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		agentBalance = models.AgentTokenBalance{
+			AgentID: input.AgentID,
+			Balance: decimal.Zero,
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	
+	agentBalance.Balance = agentBalance.Balance.Add(quote.NetAmount)
+	agentBalance.TotalReceived = agentBalance.TotalReceived.Add(quote.NetAmount)
+	if err := tx.Save(&agentBalance).Error; err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// recordPlatformIncome 记录平台收入（金额为0时不记录）
+func recordPlatformIncome(tx *gorm.DB, incomeType string, amount decimal.Decimal, referenceType string, referenceID uint) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+	return tx.Create(&models.PlatformIncome{
+		IncomeType:    incomeType,
+		Amount:        amount,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+	}).Error
+}
+
 // ==================== 提现相关 ====================
 
 // RequestWithdrawal 用户/Agent请求提现
@@ -328,6 +504,11 @@ func (s *TokenService) RequestWithdrawal(userType string, userID uint, walletAdd
 		return nil, fmt.Errorf("minimum withdrawal amount is %f", s.cfg.MinWithdrawAmount)
 	}
 	
+	// 先释放已到期的解锁奖励（未解锁部分不可提现）
+	if err := NewVestingService(s.db, s.cfg).ReleaseVested(userType, userID, walletAddress); err != nil {
+		log.Printf("Failed to release vesting before withdrawal: %v", err)
+	}
+	
 	var withdrawal *models.Withdrawal
 	
 	err := s.db.Transaction(func(tx *gorm.DB) error {
@@ -355,6 +536,17 @@ func (s *TokenService) RequestWithdrawal(userType string, userID uint, walletAdd
 			if err := tx.Where("agent_id = ?", userID).First(&balance).Error; err != nil {
 				return errors.New("agent balance not found")
 			}
+			
+			// 只能提现到经过签名验证的钱包，更换钱包后有冷却期
+			if !balance.WalletVerified || balance.WalletAddress == "" {
+				return errors.New("payout wallet not bound, please bind a wallet with a signature first")
+			}
+			if !strings.EqualFold(balance.WalletAddress, walletAddress) {
+				return errors.New("withdrawals can only be sent to the bound payout wallet")
+			}
+			if balance.WithdrawableAt != nil && time.Now().Before(*balance.WithdrawableAt) {
+				return fmt.Errorf("payout wallet changed recently, withdrawals available after %s", balance.WithdrawableAt.Format(time.RFC3339))
+			}
 			availableBalance = balance.Balance
 			
 			if availableBalance.LessThan(amount) {
@@ -371,10 +563,23 @@ func (s *TokenService) RequestWithdrawal(userType string, userID uint, walletAdd
 			return errors.New("invalid user type")
 		}
 		
-		// 计算手续费
-		feeRate := decimal.NewFromFloat(s.cfg.WithdrawFeeRate)
-		fee := amount.Mul(feeRate).Round(18)
-		netAmount := amount.Sub(fee)
+		// 计算手续费（分档/Agent专属费率/质押折扣）
+		feeInput := FeeInput{
+			FeeType:     FeeTypeWithdraw,
+			PayerType:   userType,
+			PayerWallet: walletAddress,
+			Amount:      amount,
+		}
+		if userType == "agent" {
+			feeInput.PayerID = userID
+			feeInput.AgentID = userID
+		}
+		quote, err := NewFeeService(s.db, s.cfg).Quote(tx, feeInput)
+		if err != nil {
+			return err
+		}
+		fee := quote.Fee
+		netAmount := quote.NetAmount
 		
 		withdrawal = &models.Withdrawal{
 			WalletAddress: strings.ToLower(walletAddress),
@@ -422,18 +627,21 @@ func (s *TokenService) ProcessWithdrawal(withdrawalID uint) error {
 		return err
 	}
 	
-	// 更新提现记录
+	// 更新提现记录、记录手续费收入并从锁定余额中扣除（同一事务，避免收入与余额不一致）
 	now := time.Now()
 	withdrawal.TxHash = txHash
 	withdrawal.Status = "completed"
 	withdrawal.ProcessedAt = &now
 	
-	if err := s.db.Save(&withdrawal).Error; err != nil {
-		return err
-	}
-	
-	// 从锁定余额中扣除
-	return s.confirmWithdrawal(withdrawal.UserType, withdrawal.UserID, withdrawal.WalletAddress, withdrawal.Amount)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&withdrawal).Error; err != nil {
+			return err
+		}
+		if err := recordPlatformIncome(tx, "withdraw_fee", withdrawal.Fee, "withdrawal", withdrawal.ID); err != nil {
+			return err
+		}
+		return s.confirmWithdrawal(tx, withdrawal.UserType, withdrawal.UserID, withdrawal.WalletAddress, withdrawal.Amount)
+	})
 }
 
 // sendTokenTransfer 发送ERC20代币转账
@@ -535,16 +743,16 @@ func (s *TokenService) unlockBalance(userType string, userID uint, walletAddress
 }
 
 // confirmWithdrawal 确认提现（从锁定余额中扣除）
-func (s *TokenService) confirmWithdrawal(userType string, userID uint, walletAddress string, amount decimal.Decimal) error {
+func (s *TokenService) confirmWithdrawal(tx *gorm.DB, userType string, userID uint, walletAddress string, amount decimal.Decimal) error {
 	if userType == "user" {
-		return s.db.Model(&models.TokenBalance{}).
+		return tx.Model(&models.TokenBalance{}).
 			Where("wallet_address = ?", strings.ToLower(walletAddress)).
 			Updates(map[string]interface{}{
 				"locked_balance":   gorm.Expr("locked_balance - ?", amount),
 				"total_withdrawn":  gorm.Expr("total_withdrawn + ?", amount),
 			}).Error
 	} else {
-		return s.db.Model(&models.AgentTokenBalance{}).
+		return tx.Model(&models.AgentTokenBalance{}).
 			Where("agent_id = ?", userID).
 			Updates(map[string]interface{}{
 				"locked_balance":   gorm.Expr("locked_balance - ?", amount),
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/config"
+	"github.com/LawrenceLiang-BTC/funnyai-backend/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 解锁规则：
+//   - 奖励按 RewardConfig.VestingDays 从发放时刻起线性解锁
+//   - 已解锁部分定期转入可用余额（Balance），未解锁部分记在 VestingBalance
+//   - 未解锁部分可以直接用于打赏，打赏扣的是解锁计划的末尾（最晚解锁的部分），
+//     已到期的部分不受影响
+type VestingService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewVestingService(db *gorm.DB, cfg *config.Config) *VestingService {
+	return &VestingService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// VestingScheduleItem 解锁计划（用于余额接口展示）
+type VestingScheduleItem struct {
+	RewardID    uint            `json:"rewardId"`
+	RewardType  string          `json:"rewardType"`
+	Amount      decimal.Decimal `json:"amount"`
+	Released    decimal.Decimal `json:"released"`
+	Spent       decimal.Decimal `json:"spent"`
+	Locked      decimal.Decimal `json:"locked"`      // 尚未解锁
+	DailyUnlock decimal.Decimal `json:"dailyUnlock"` // 每日解锁量
+	StartAt     time.Time       `json:"startAt"`
+	EndAt       time.Time       `json:"endAt"`
+}
+
+// CreateGrant 创建解锁计划（在奖励发放事务中调用，VestingBalance 由调用方增加）
+func (s *VestingService) CreateGrant(tx *gorm.DB, reward *models.Reward, vestingDays int) error {
+	now := time.Now()
+	grant := models.VestingGrant{
+		RecipientType:   reward.RecipientType,
+		RecipientID:     reward.RecipientID,
+		RecipientWallet: reward.RecipientWallet,
+		RewardID:        reward.ID,
+		RewardType:      reward.RewardType,
+		Amount:          reward.Amount,
+		Released:        decimal.Zero,
+		Spent:           decimal.Zero,
+		StartAt:         now,
+		EndAt:           now.AddDate(0, 0, vestingDays),
+	}
+	return tx.Create(&grant).Error
+}
+
+// ReleaseVested 将已到期的部分转入可用余额
+// 解锁计划加行锁读取，后台释放、提现前释放和打赏扣除并发时不会重复入账
+func (s *VestingService) ReleaseVested(recipientType string, recipientID uint, walletAddress string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var grants []models.VestingGrant
+		if err := s.grantQuery(tx, recipientType, recipientID, walletAddress).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("completed = ?", false).
+			Order("end_at desc, id desc").
+			Find(&grants).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		total := decimal.Zero
+		for i := range grants {
+			g := &grants[i]
+			releasable := releasableAmount(g, now)
+			if !releasable.IsPositive() {
+				continue
+			}
+
+			g.Released = g.Released.Add(releasable)
+			g.Completed = g.Released.Add(g.Spent).GreaterThanOrEqual(g.Amount)
+			if err := tx.Save(g).Error; err != nil {
+				return err
+			}
+			total = total.Add(releasable)
+		}
+
+		if !total.IsPositive() {
+			return nil
+		}
+		return s.balanceQuery(tx, recipientType, recipientID, walletAddress).
+			Updates(map[string]interface{}{
+				"balance":         gorm.Expr("balance + ?", total),
+				"vesting_balance": gorm.Expr("vesting_balance - ?", total),
+			}).Error
+	})
+}
+
+// SpendVesting 使用未解锁余额（打赏时调用，余额字段由调用方扣减）
+// 优先扣除最晚到期的计划，且每个计划从末尾扣起
+func (s *VestingService) SpendVesting(tx *gorm.DB, recipientType string, recipientID uint, walletAddress string, amount decimal.Decimal) error {
+	// 与 ReleaseVested 相同的加锁顺序，避免死锁
+	var grants []models.VestingGrant
+	if err := s.grantQuery(tx, recipientType, recipientID, walletAddress).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("completed = ?", false).
+		Order("end_at desc, id desc").
+		Find(&grants).Error; err != nil {
+		return err
+	}
+
+	remaining := amount
+	for i := range grants {
+		if !remaining.IsPositive() {
+			break
+		}
+		g := &grants[i]
+		locked := g.Amount.Sub(g.Released).Sub(g.Spent)
+		if !locked.IsPositive() {
+			continue
+		}
+
+		use := decimal.Min(locked, remaining)
+		g.Spent = g.Spent.Add(use)
+		g.Completed = g.Released.Add(g.Spent).GreaterThanOrEqual(g.Amount)
+		if err := tx.Save(g).Error; err != nil {
+			return err
+		}
+		remaining = remaining.Sub(use)
+	}
+
+	if remaining.IsPositive() {
+		return errors.New("insufficient vesting balance")
+	}
+	return nil
+}
+
+// GetSchedule 获取未完成的解锁计划
+func (s *VestingService) GetSchedule(recipientType string, recipientID uint, walletAddress string) ([]VestingScheduleItem, error) {
+	var grants []models.VestingGrant
+	if err := s.grantQuery(s.db, recipientType, recipientID, walletAddress).
+		Where("completed = ?", false).
+		Order("end_at asc").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]VestingScheduleItem, 0, len(grants))
+	for _, g := range grants {
+		days := decimal.NewFromFloat(g.EndAt.Sub(g.StartAt).Hours() / 24)
+		dailyUnlock := g.Amount
+		if days.IsPositive() {
+			dailyUnlock = g.Amount.Div(days).Round(18)
+		}
+		items = append(items, VestingScheduleItem{
+			RewardID:    g.RewardID,
+			RewardType:  g.RewardType,
+			Amount:      g.Amount,
+			Released:    g.Released,
+			Spent:       g.Spent,
+			Locked:      g.Amount.Sub(g.Released).Sub(g.Spent),
+			DailyUnlock: dailyUnlock,
+			StartAt:     g.StartAt,
+			EndAt:       g.EndAt,
+		})
+	}
+	return items, nil
+}
+
+// StartVestingReleaser 定期释放所有到期的解锁额度（在单独goroutine中运行）
+func (s *VestingService) StartVestingReleaser(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Vesting releaser stopped")
+			return
+		case <-ticker.C:
+			s.releaseAll()
+		}
+	}
+}
+
+// releaseAll 遍历所有有未完成计划的账户
+func (s *VestingService) releaseAll() {
+	type recipient struct {
+		RecipientType   string
+		RecipientID     uint
+		RecipientWallet string
+	}
+	var recipients []recipient
+	if err := s.db.Model(&models.VestingGrant{}).
+		Select("DISTINCT recipient_type, recipient_id, recipient_wallet").
+		Where("completed = ?", false).
+		Scan(&recipients).Error; err != nil {
+		log.Printf("Failed to get vesting recipients: %v", err)
+		return
+	}
+
+	for _, r := range recipients {
+		if err := s.ReleaseVested(r.RecipientType, r.RecipientID, r.RecipientWallet); err != nil {
+			log.Printf("Failed to release vesting for %s #%d %s: %v", r.RecipientType, r.RecipientID, r.RecipientWallet, err)
+		}
+	}
+}
+
+// releasableAmount 计算当前可释放额度
+// 线性已解锁额 - 已释放，且不超过计划剩余（被打赏用掉的部分从末尾扣除）
+func releasableAmount(g *models.VestingGrant, now time.Time) decimal.Decimal {
+	vested := g.Amount
+	if now.Before(g.EndAt) {
+		total := g.EndAt.Sub(g.StartAt).Seconds()
+		elapsed := now.Sub(g.StartAt).Seconds()
+		if elapsed <= 0 {
+			return decimal.Zero
+		}
+		vested = g.Amount.Mul(decimal.NewFromFloat(elapsed / total)).Round(18)
+	}
+
+	releasable := decimal.Min(vested.Sub(g.Released), g.Amount.Sub(g.Released).Sub(g.Spent))
+	if releasable.IsNegative() {
+		return decimal.Zero
+	}
+	return releasable
+}
+
+// grantQuery 按账户筛选解锁计划（用户按钱包，Agent按ID）
+func (s *VestingService) grantQuery(db *gorm.DB, recipientType string, recipientID uint, walletAddress string) *gorm.DB {
+	query := db.Model(&models.VestingGrant{}).Where("recipient_type = ?", recipientType)
+	if recipientType == "agent" {
+		return query.Where("recipient_id = ?", recipientID)
+	}
+	return query.Where("recipient_wallet = ?", strings.ToLower(walletAddress))
+}
+
+// balanceQuery 定位账户余额记录
+func (s *VestingService) balanceQuery(db *gorm.DB, recipientType string, recipientID uint, walletAddress string) *gorm.DB {
+	if recipientType == "agent" {
+		return db.Model(&models.AgentTokenBalance{}).Where("agent_id = ?", recipientID)
+	}
+	return db.Model(&models.TokenBalance{}).Where("wallet_address = ?", strings.ToLower(walletAddress))
+}
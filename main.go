@@ -30,6 +30,40 @@ func main() {
 		log.Printf("Warning: Failed to initialize reward pool: %v", err)
 	}
 
+	// 启动奖励解锁释放任务（关闭解锁模式后仍需释放存量计划）
+	vestingService := services.NewVestingService(db, cfg)
+	go vestingService.StartVestingReleaser(context.Background())
+
+	// 启动资金流每日汇总任务
+	analyticsService := services.NewAnalyticsService(db, cfg)
+	go analyticsService.StartAnalyticsAggregator(context.Background())
+
+	// 启动设备指纹批量写入任务
+	riskService := services.NewRiskService(db, cfg)
+	go riskService.StartFingerprintRecorder(context.Background())
+
+	// 启动热度重算任务
+	hotnessService := services.NewHotnessService(db, cfg)
+	go hotnessService.StartHotnessUpdater(context.Background())
+
+	// 启动全文搜索索引任务
+	searchService := services.NewSearchService(db, cfg)
+	go searchService.StartSearchIndexer(context.Background())
+
+	// 启动储备金证明任务
+	if cfg.TokenEnabled {
+		reserveService := services.NewReserveService(db, cfg)
+		go reserveService.StartReserveProver(context.Background())
+
+		// 启动质押分红任务
+		stakingService := services.NewStakingService(db, cfg)
+		go stakingService.StartStakingDistributor(context.Background())
+
+		// 启动悬赏过期结算任务
+		bountyService := services.NewBountyService(db, cfg)
+		go bountyService.StartBountySettler(context.Background())
+	}
+
 	// 启动代币充值监听服务（如果启用）
 	if cfg.TokenEnabled && cfg.PlatformWallet != "" {
 		tokenService, err := services.NewTokenService(db, cfg)